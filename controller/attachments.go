@@ -0,0 +1,292 @@
+// Files attached to articles.
+package controller
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject/errors"
+	"github.com/gorilla/mux"
+)
+
+// errTooLarge is returned for an attachment above maxUploadSize.
+var errTooLarge = errors.New("Error: The attachment is too large.")
+
+// maxUploadSize is the largest attachment accepted in bytes, set with the MAX_UPLOAD_SIZE env variable.
+var maxUploadSize = int64(envInt("MAX_UPLOAD_SIZE", 10<<20))
+
+// maxUploadParts is the largest number of parts of an upload, set with the MAX_UPLOAD_PARTS env variable.
+var maxUploadParts = envInt("MAX_UPLOAD_PARTS", 10)
+
+// maxUploadBody bounds the whole upload request: every part at its largest and room for their headers.
+var maxUploadBody = maxUploadSize*int64(maxUploadParts) + 1<<20
+
+// inlineTypes are shown in the browser, raster images that can not run scripts; every other type, svg
+// and html included, is downloaded.
+var inlineTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/bmp":  true,
+	"image/avif": true,
+}
+
+// attachmentURL returns where the attachment is served.
+func attachmentURL(r *http.Request, a Attachment) string {
+	return absoluteURL(r, fmt.Sprintf("/articles/%d/attachments/%d", a.ArticleID, a.ID))
+}
+
+// detectContentType sniffs the type of the content from its first bytes, falling back to the file
+// extension when the content says nothing more specific. The type sent by the client is not trusted.
+func detectContentType(head []byte, fileName string) string {
+	contentType := http.DetectContentType(head)
+	if contentType == "application/octet-stream" || strings.HasPrefix(contentType, "text/plain") {
+		if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName))); byExt != "" {
+			return byExt
+		}
+	}
+	return contentType
+}
+
+// attachmentParam loads the attachment of the url, which has to belong to the article.
+func (h *Handler) attachmentParam(w http.ResponseWriter, r *http.Request, article Article) (Attachment, bool) {
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["aid"])
+	if err != nil {
+		http.Error(w, "Error: getting the attachment ID.", http.StatusUnprocessableEntity)
+		return Attachment{}, false
+	}
+	attachment, err := h.database.GetAttachment(attachmentID)
+	if err == nil && attachment.ArticleID != article.ID {
+		http.Error(w, "Error: Failed to retrive the attachment with ID, not found", http.StatusNotFound)
+		return Attachment{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return Attachment{}, false
+	}
+	return attachment, true
+}
+
+// storeAttachment writes one uploaded file to the blob store and records it.
+func (h *Handler) storeAttachment(r *http.Request, article Article, part io.Reader, fileName string) (Attachment, error) {
+	random := make([]byte, 16)
+	rand.Read(random)
+	attachment := Attachment{
+		ArticleID: article.ID,
+		FileName:  filepath.Base(fileName),
+		User:      currentUser(r),
+		CreatedAt: time.Now().UTC(),
+		Key:       fmt.Sprintf("articles/%d/%s", article.ID, hex.EncodeToString(random)),
+	}
+
+	content := bufio.NewReaderSize(part, 512)
+	head, _ := content.Peek(512)
+	attachment.ContentType = detectContentType(head, attachment.FileName)
+
+	// one byte more than allowed tells a file at the limit from a larger one.
+	size, err := h.blobs.Put(attachment.Key, io.LimitReader(content, maxUploadSize+1))
+	if err == nil && size > maxUploadSize {
+		err = errTooLarge
+	}
+	if err != nil {
+		h.blobs.Delete(attachment.Key)
+		return attachment, err
+	}
+	attachment.Size = size
+
+	attachment, err = h.database.AddAttachment(attachment)
+	if err != nil {
+		h.blobs.Delete(attachment.Key)
+		return attachment, err
+	}
+	attachment.URL = attachmentURL(r, attachment)
+	return attachment, nil
+}
+
+// UploadAttachments stores the files of a multipart/form-data request as attachments of article 'id' - POST METHOD.
+// Every part with a file name is stored, only the author of the article and editors may upload.
+func (h *Handler) UploadAttachments(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	if !h.canEdit(r, article) {
+		http.Error(w, "Error: Only the author of the article or an editor can attach files.", http.StatusForbidden)
+		return
+	}
+	body := &uploadBody{ReadCloser: http.MaxBytesReader(w, r.Body, maxUploadBody)}
+	r.Body = body
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Error: Expected a multipart/form-data upload.", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// an upload is stored whole or not at all, a failing part removes the files stored before it.
+	result := AttachmentList{ArticleID: article.ID, Attachments: []Attachment{}}
+	fail := func(message string, status int) {
+		for _, a := range result.Attachments {
+			if err := h.removeAttachment(a); err != nil {
+				log.Println("Error: removing attachment", a.ID, "of a failed upload,", err)
+			}
+		}
+		http.Error(w, message, status)
+	}
+	for parts := 1; ; parts++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && body.tooLarge {
+			fail("Error: The upload is larger than "+strconv.FormatInt(maxUploadBody, 10)+" bytes.", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			fail("Error: Reading the upload, "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if parts > maxUploadParts {
+			fail("Error: The upload holds more than "+strconv.Itoa(maxUploadParts)+" parts.", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if part.FileName() == "" {
+			continue
+		}
+		attachment, err := h.storeAttachment(r, article, part, part.FileName())
+		if err == errTooLarge {
+			fail("Error: "+part.FileName()+" is larger than "+strconv.FormatInt(maxUploadSize, 10)+" bytes.", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil && body.tooLarge {
+			fail("Error: The upload is larger than "+strconv.FormatInt(maxUploadBody, 10)+" bytes.", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			log.Println(err.Error())
+			fail(err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Attachments = append(result.Attachments, attachment)
+	}
+	if len(result.Attachments) == 0 {
+		http.Error(w, "Error: The upload holds no file.", http.StatusUnprocessableEntity)
+		return
+	}
+	result.Count = len(result.Attachments)
+	writeJsonStatus(w, http.StatusCreated, result)
+}
+
+// uploadBody notes when the upload is read past the limit of http.MaxBytesReader, as the errors of
+// the multipart reader do not always tell.
+type uploadBody struct {
+	io.ReadCloser
+	tooLarge bool
+}
+
+func (b *uploadBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && strings.Contains(err.Error(), "request body too large") {
+		b.tooLarge = true
+	}
+	return n, err
+}
+
+// ListAttachments lists the attachments of article 'id' - GET METHOD.
+func (h *Handler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	attachments, err := h.database.ListAttachments(article.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	for i := range attachments {
+		attachments[i].URL = attachmentURL(r, attachments[i])
+	}
+	writeJson(w, AttachmentList{ArticleID: article.ID, Count: len(attachments), Attachments: attachments})
+}
+
+// GetAttachment serves the content of attachment 'aid' with its content type - GET METHOD.
+// Range, If-Range and If-Modified-Since requests are answered as http.ServeContent does.
+func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	attachment, ok := h.attachmentParam(w, r, article)
+	if !ok {
+		return
+	}
+
+	blob, err := h.blobs.Open(attachment.Key)
+	if err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	defer blob.Close()
+
+	disposition := "attachment"
+	if mediaType, _, _ := mime.ParseMediaType(attachment.ContentType); inlineTypes[mediaType] {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// opened anyway, the content runs without scripts and apart from the site.
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	// the content of an attachment never changes.
+	w.Header().Set("ETag", `"attachment-`+strconv.Itoa(attachment.ID)+`"`)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, blob)
+}
+
+// DeleteAttachment deletes attachment 'aid', by its uploader or an editor - DELETE METHOD.
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	attachment, ok := h.attachmentParam(w, r, article)
+	if !ok {
+		return
+	}
+	if attachment.User != currentUser(r) && !hasRole(r, editorRole) {
+		http.Error(w, "Error: Only the user who uploaded the file or an editor can delete it.", http.StatusForbidden)
+		return
+	}
+
+	if err := h.removeAttachment(attachment); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeAttachment deletes the record of the attachment and then its content, a content left over
+// by a failure is only logged as nothing points to it anymore.
+func (h *Handler) removeAttachment(a Attachment) error {
+	if err := h.database.DeleteAttachment(a.ID); err != nil {
+		return err
+	}
+	if err := h.blobs.Delete(a.Key); err != nil {
+		log.Println("Error: removing the content of attachment", a.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pngHeader is enough of a PNG file for content sniffing.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestDetectContentType(t *testing.T) {
+	assert.Equal(t, "image/png", detectContentType(pngHeader, "photo.txt"))
+	assert.Equal(t, "text/csv; charset=utf-8", detectContentType([]byte("a,b\n1,2\n"), "data.csv"))
+	assert.Equal(t, "application/octet-stream", detectContentType([]byte{0, 1, 2}, "blob"))
+}
+
+// uploadRequest builds a multipart request uploading files by name.
+func uploadRequest(url string, files map[string][]byte) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, content := range files {
+		part, _ := form.CreateFormFile("file", name)
+		part.Write(content)
+	}
+	form.Close()
+	req, _ := http.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestHandler_Attachments(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}})
+
+	rr := serve(h, "POST", "http://localhost:8984/articles/1/attachments", `{}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	rr = serveRequest(h, uploadRequest("http://localhost:8984/articles/1/attachments", map[string][]byte{
+		"photo.png": append(pngHeader, bytes.Repeat([]byte{1}, 100)...),
+	}))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var uploaded AttachmentList
+	json.Unmarshal(rr.Body.Bytes(), &uploaded)
+	assert.Equal(t, 1, uploaded.Count)
+	photo := uploaded.Attachments[0]
+	assert.Equal(t, "image/png", photo.ContentType)
+	assert.Equal(t, int64(len(pngHeader)+100), photo.Size)
+	assert.Equal(t, "http://localhost:8984/articles/1/attachments/1", photo.URL)
+
+	rr = serve(h, "GET", photo.URL, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.Equal(t, pngHeader, rr.Body.Bytes()[:len(pngHeader)])
+
+	req, _ := http.NewRequest("GET", photo.URL, nil)
+	req.Header.Set("Range", "bytes=1-3")
+	rr = serveRequest(h, req)
+	assert.Equal(t, http.StatusPartialContent, rr.Code)
+	assert.Equal(t, "PNG", rr.Body.String())
+
+	// purging the article removes its attachments.
+	stored, _ := h.database.GetAttachment(photo.ID)
+	serve(h, "DELETE", "http://localhost:8984/articles/1", "")
+	rr = serve(h, "DELETE", "http://localhost:8984/trash/1", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	_, err := h.database.GetAttachment(photo.ID)
+	assert.NotNil(t, err)
+	_, err = h.blobs.Open(stored.Key)
+	assert.NotNil(t, err)
+}
+
+func TestHandler_AttachmentTooLarge(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}})
+	defer func(size int64) { maxUploadSize = size }(maxUploadSize)
+	maxUploadSize = 10
+
+	rr := serveRequest(h, uploadRequest("http://localhost:8984/articles/1/attachments", map[string][]byte{
+		"big.bin": bytes.Repeat([]byte{1}, 11),
+	}))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	attachments, _ := h.database.ListAttachments(1)
+	assert.Equal(t, 0, len(attachments))
+}
+
+// orderedUploadRequest builds an upload with the files in the order given, name then content.
+func orderedUploadRequest(url string, files ...string) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for i := 0; i+1 < len(files); i += 2 {
+		part, _ := form.CreateFormFile("file", files[i])
+		part.Write([]byte(files[i+1]))
+	}
+	form.Close()
+	req, _ := http.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestHandler_AttachmentUploadAllOrNothing(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}})
+	defer func(size, body int64, parts int) {
+		maxUploadSize, maxUploadBody, maxUploadParts = size, body, parts
+	}(maxUploadSize, maxUploadBody, maxUploadParts)
+	maxUploadSize, maxUploadParts = 10, 2
+
+	// the files stored before a failing part are removed with their contents.
+	rr := serveRequest(h, orderedUploadRequest("http://localhost:8984/articles/1/attachments", "a.txt", "small", "big.bin", "far too large"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	rr = serveRequest(h, orderedUploadRequest("http://localhost:8984/articles/1/attachments", "a.txt", "a", "b.txt", "b", "c.txt", "c"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), "more than 2 parts")
+	maxUploadSize, maxUploadBody = 1000, 300
+	rr = serveRequest(h, orderedUploadRequest("http://localhost:8984/articles/1/attachments", "a.txt", "a", "b.txt", strings.Repeat("b", 200)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	attachments, _ := h.database.ListAttachments(1)
+	assert.Equal(t, 0, len(attachments))
+	blobs := 0
+	filepath.Walk(h.blobs.(*LocalBlobStore).dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			blobs += 1
+		}
+		return nil
+	})
+	assert.Equal(t, 0, blobs)
+}
+
+func TestHandler_AttachmentDisposition(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}})
+
+	serveRequest(h, uploadRequest("http://localhost:8984/articles/1/attachments", map[string][]byte{
+		"photo.png": pngHeader,
+	}))
+	serveRequest(h, uploadRequest("http://localhost:8984/articles/1/attachments", map[string][]byte{
+		"logo.svg": []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`),
+	}))
+
+	rr := serve(h, "GET", "http://localhost:8984/articles/1/attachments/1", "")
+	assert.Equal(t, `inline; filename=photo.png`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "sandbox", rr.Header().Get("Content-Security-Policy"))
+
+	// svg can run scripts, it is only downloaded.
+	rr = serve(h, "GET", "http://localhost:8984/articles/1/attachments/2", "")
+	assert.Equal(t, "image/svg+xml", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=logo.svg`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "sandbox", rr.Header().Get("Content-Security-Policy"))
+}
@@ -0,0 +1,156 @@
+// Append-only audit log of the changes made to articles.
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+
+	// user recorded for the changes made by background jobs.
+	systemUser = "system"
+)
+
+type contextKey string
+
+const requestIDKey contextKey = "request_id"
+
+// RequestID tags every request with the X-Request-ID header sent by the client, or a generated one,
+// and echoes it on the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" || len(id) > 128 {
+			b := make([]byte, 16)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// requestID returns the id RequestID gave the request.
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey).(string)
+	return id
+}
+
+// audit appends an entry for a change of an article made by the request, a nil request stands for
+// a background job. An empty before or after (ID 0) is left out. The change itself already happened,
+// so a failure is only logged. The sitemap follows the change too.
+func (h *Handler) audit(r *http.Request, action string, before, after Article) {
+	entry := AuditEntry{
+		Timestamp: time.Now().UTC(),
+		User:      systemUser,
+		Action:    action,
+	}
+	if r != nil {
+		entry.User = currentUser(r)
+		entry.RequestID = requestID(r)
+	}
+	if before.ID != 0 {
+		entry.ArticleID = before.ID
+		entry.Before = &before
+	}
+	if after.ID != 0 {
+		entry.ArticleID = after.ID
+		entry.After = &after
+	}
+	if _, err := h.database.AddAuditEntry(entry); err != nil {
+		log.Println("Error: recording audit entry", action, entry.ArticleID, err)
+	}
+	h.sitemap.change(before, after)
+}
+
+// auditTag appends an entry and a revision for every article whose tags 'from' was replaced by 'to'.
+func (h *Handler) auditTag(r *http.Request, action, from, to string, articles ArticlesArr) {
+	for _, before := range articles {
+		after := copyArticle(before)
+		after.Tags, after.Version = replaceTag(before.Tags, from, to), before.Version+1
+		h.audit(r, action, before, after)
+		h.recordRevision(r, action, before, after)
+	}
+}
+
+// auditFilter reads the filters of the audit endpoints.
+func auditFilter(r *http.Request) (AuditFilter, error) {
+	params := r.URL.Query()
+	filter := AuditFilter{User: params.Get("user"), Action: params.Get("action")}
+
+	var err error
+	if filter.ArticleID, err = intParam(params.Get("article"), 0); err != nil {
+		return filter, err
+	}
+	from, to, err := dateRange(params)
+	if err != nil {
+		return filter, err
+	}
+	if from != "" {
+		filter.From, _ = time.Parse(dateLayout, from)
+	}
+	if to != "" {
+		// 'to' is inclusive.
+		day, _ := time.Parse(dateLayout, to)
+		filter.To = day.AddDate(0, 0, 1)
+	}
+	return filter, nil
+}
+
+// ListAudit returns the latest audit entries, oldest first - GET METHOD.
+// Filters are 'user', 'action', 'article' (id) and 'from'/'to' dates eg: 20160112, 'limit' defaults to 100.
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilter(r)
+	if err != nil {
+		http.Error(w, "Error: Invalid filter, "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	filter.Limit, err = intParam(r.URL.Query().Get("limit"), defaultAuditLimit)
+	if err != nil || filter.Limit <= 0 || filter.Limit > maxAuditLimit {
+		http.Error(w, "Error: Invalid limit, expected 1 to "+strconv.Itoa(maxAuditLimit)+".", http.StatusUnprocessableEntity)
+		return
+	}
+
+	entries, err := h.database.ListAuditEntries(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	writeJson(w, AuditLog{Count: len(entries), Entries: entries})
+}
+
+// ExportAudit streams every audit entry matching the filters of ListAudit as JSON lines - GET METHOD.
+func (h *Handler) ExportAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilter(r)
+	if err != nil {
+		http.Error(w, "Error: Invalid filter, "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	entries, err := h.database.ListAuditEntries(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	encoder := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := encoder.Encode(e); err != nil {
+			log.Println("Error: exporting audit log,", err)
+			return
+		}
+	}
+}
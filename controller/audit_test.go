@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_AuditLog(t *testing.T) {
+	h := newMemoryHandler(t)
+
+	req, _ := http.NewRequest("POST", "http://localhost:8984/articles", strings.NewReader(`{"title":"One","date":"2018-10-04","body":"first","tags":["aaa"]}`))
+	req.Header.Set("X-Request-ID", "req-1")
+	rr := serveRequest(h, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "req-1", rr.Header().Get("X-Request-ID"))
+
+	serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"One","date":"2018-10-04","body":"changed","tags":["aaa"]}`)
+	serve(h, "POST", "http://localhost:8984/tags/aaa/rename", `{"name":"bbb"}`)
+	serve(h, "DELETE", "http://localhost:8984/articles/1", "")
+
+	rr = serve(h, "GET", "http://localhost:8984/admin/audit", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var log AuditLog
+	json.Unmarshal(rr.Body.Bytes(), &log)
+	assert.Equal(t, 4, log.Count)
+	actions := []string{}
+	for _, e := range log.Entries {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{"create", "update", "rename_tag", "delete"}, actions)
+
+	created := log.Entries[0]
+	assert.Equal(t, "test", created.User)
+	assert.Equal(t, "req-1", created.RequestID)
+	assert.Nil(t, created.Before)
+	assert.Equal(t, "first", created.After.Body)
+	assert.Equal(t, []string{"aaa"}, log.Entries[2].Before.Tags)
+	assert.Equal(t, []string{"bbb"}, log.Entries[2].After.Tags)
+	assert.Nil(t, log.Entries[3].After)
+
+	rr = serve(h, "GET", "http://localhost:8984/admin/audit?action=update&article=1", "")
+	json.Unmarshal(rr.Body.Bytes(), &log)
+	assert.Equal(t, 1, log.Count)
+	assert.Equal(t, "changed", log.Entries[0].After.Body)
+
+	rr = serve(h, "GET", "http://localhost:8984/admin/audit/export?user=test", "")
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	lines := 0
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var entry AuditEntry
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &entry))
+		lines += 1
+		assert.Equal(t, lines, entry.Seq)
+	}
+	assert.Equal(t, 4, lines)
+}
+
+func TestHandler_AuditRequiresAdmin(t *testing.T) {
+	h := newMemoryHandler(t)
+	accounts["reader"] = account{password: "password"}
+	defer delete(accounts, "reader")
+
+	rr := serveAs(h, "reader", "GET", "http://localhost:8984/admin/audit", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -0,0 +1,207 @@
+// Author profiles and the articles they wrote.
+package controller
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"awesomeProject/errors"
+	"github.com/gorilla/mux"
+)
+
+// authorOf returns the author profile of user, creating one named after the user on first use.
+func (h *Handler) authorOf(user string) (Author, error) {
+	author, err := h.database.GetAuthorByUser(user)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		return author, err
+	}
+	author = Author{User: user, Name: user}
+	author.ID, err = h.database.AddAuthor(author)
+	if err == errAuthorExists {
+		// created concurrently by another request of the user.
+		return h.database.GetAuthorByUser(user)
+	}
+	return author, err
+}
+
+// readAuthor decodes and validates the author in the request body.
+func readAuthor(r *http.Request) (Author, error) {
+	var author Author
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err == nil {
+		err = decodeBody(r, body, &author)
+	}
+	if err != nil {
+		return author, errors.New("Error: Unmarshalling author, " + err.Error())
+	}
+
+	author.Name = strings.TrimSpace(author.Name)
+	if author.Name == "" {
+		return author, errors.New("Error: The author 'name' is required.")
+	}
+	if author.AvatarURL != "" {
+		u, err := url.Parse(author.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return author, errors.New("Error: The 'avatar_url' must be an absolute http or https url.")
+		}
+	}
+	return author, nil
+}
+
+// authorParam reads the author id of the url and loads the author.
+func (h *Handler) authorParam(w http.ResponseWriter, r *http.Request) (Author, bool) {
+	authorID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Error: getting the author ID.", http.StatusUnprocessableEntity)
+		return Author{}, false
+	}
+	author, err := h.database.GetAuthor(authorID)
+	if err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return Author{}, false
+	}
+	return author, true
+}
+
+// canEditAuthor reports whether the caller of the request may change the author, only the user
+// of the profile and admins may.
+func canEditAuthor(r *http.Request, author Author) bool {
+	return author.User == currentUser(r) || hasRole(r, "admin")
+}
+
+// CreateAuthor creates the author profile of the authenticated user - POST METHOD.
+// Admins may create the profile of another user by giving its 'user'.
+func (h *Handler) CreateAuthor(w http.ResponseWriter, r *http.Request) {
+	author, err := readAuthor(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if author.User == "" {
+		author.User = currentUser(r)
+	}
+	if !canEditAuthor(r, author) {
+		http.Error(w, "Error: admin role required", http.StatusForbidden)
+		return
+	}
+
+	author.ID, err = h.database.AddAuthor(author)
+	if err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Location", absoluteURL(r, "/authors/"+strconv.Itoa(author.ID)))
+	writeJsonStatus(w, http.StatusCreated, author)
+}
+
+// ListAuthors lists all authors - GET METHOD.
+func (h *Handler) ListAuthors(w http.ResponseWriter, r *http.Request) {
+	authors, err := h.database.ListAuthors()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	writeJson(w, AuthorList{Count: len(authors), Authors: authors})
+}
+
+// GetAuthor retrives the author with 'id' - GET METHOD.
+func (h *Handler) GetAuthor(w http.ResponseWriter, r *http.Request) {
+	author, ok := h.authorParam(w, r)
+	if !ok {
+		return
+	}
+	writeJson(w, author)
+}
+
+// UpdateAuthor replaces name, bio, contact and avatar url of the author with 'id' - PUT METHOD.
+func (h *Handler) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.authorParam(w, r)
+	if !ok {
+		return
+	}
+	if !canEditAuthor(r, current) {
+		http.Error(w, "Error: Only the author or an admin can change the profile.", http.StatusForbidden)
+		return
+	}
+	author, err := readAuthor(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// the user of a profile never changes.
+	author.ID, author.User = current.ID, current.User
+	if err := h.database.UpdateAuthor(author); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	writeJson(w, author)
+}
+
+// DeleteAuthor deletes the author with 'id', which must not have articles - DELETE METHOD.
+func (h *Handler) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	author, ok := h.authorParam(w, r)
+	if !ok {
+		return
+	}
+	if !canEditAuthor(r, author) {
+		http.Error(w, "Error: Only the author or an admin can delete the profile.", http.StatusForbidden)
+		return
+	}
+
+	// articles in trash still point to the author.
+	for _, deleted := range []bool{false, true} {
+		articles, err := h.database.ListArticles(ArticleFilter{AuthorID: author.ID, Deleted: deleted})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Println(err.Error())
+			return
+		}
+		if len(articles) > 0 {
+			http.Error(w, "Error: The author still has articles.", http.StatusConflict)
+			return
+		}
+	}
+
+	if err := h.database.DeleteAuthor(author.ID); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAuthorArticles lists the articles of the author with 'id' - GET METHOD.
+func (h *Handler) GetAuthorArticles(w http.ResponseWriter, r *http.Request) {
+	author, ok := h.authorParam(w, r)
+	if !ok {
+		return
+	}
+
+	articles, err := h.database.ListArticles(ArticleFilter{AuthorID: author.ID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	articles = readable(r, articles)
+	ids := []int{}
+	for _, a := range articles {
+		ids = append(ids, a.ID)
+	}
+	counts := h.commentCounts(ids...)
+	for i := range articles {
+		articles[i].CommentCount = counts[articles[i].ID]
+	}
+	writeJson(w, AuthorArticles{Author: author, Count: len(articles), Articles: articles})
+}
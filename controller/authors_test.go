@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Authors(t *testing.T) {
+	h := newMemoryHandler(t)
+	accounts["writer"] = account{password: "password"}
+	defer delete(accounts, "writer")
+
+	rr := serveAs(h, "writer", "POST", "http://localhost:8984/authors", `{"name":"Writer","avatar_url":"ftp://x"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	rr = serveAs(h, "writer", "POST", "http://localhost:8984/authors", `{"name":"Writer","bio":"Writes.","avatar_url":"https://example.com/w.png"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "http://localhost:8984/authors/1", rr.Header().Get("Location"))
+	rr = serveAs(h, "writer", "POST", "http://localhost:8984/authors", `{"name":"Again"}`)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = serveAs(h, "writer", "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"first","tags":["aaa"]}`)
+	var article Article
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, 1, article.AuthorID)
+
+	// the test user gets a profile on its first article.
+	rr = serve(h, "POST", "http://localhost:8984/articles", `{"title":"Two","date":"2018-10-04","body":"second","tags":["aaa"]}`)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, 2, article.AuthorID)
+	author, _ := h.database.GetAuthor(2)
+	assert.Equal(t, "test", author.Name)
+
+	// the draft is only listed to editors.
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/authors/1/articles", "")
+	var articles AuthorArticles
+	json.Unmarshal(rr.Body.Bytes(), &articles)
+	assert.Equal(t, 0, articles.Count)
+	rr = serve(h, "GET", "http://localhost:8984/authors/1/articles", "")
+	json.Unmarshal(rr.Body.Bytes(), &articles)
+	assert.Equal(t, 1, articles.Count)
+	assert.Equal(t, "Writer", articles.Author.Name)
+
+	rr = serveAs(h, "writer", "PUT", "http://localhost:8984/authors/2", `{"name":"Not me"}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "writer", "PUT", "http://localhost:8984/authors/1", `{"name":"W. Riter","user":"test"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	author, _ = h.database.GetAuthor(1)
+	assert.Equal(t, "W. Riter", author.Name)
+	assert.Equal(t, "writer", author.User)
+
+	rr = serveAs(h, "writer", "DELETE", "http://localhost:8984/authors/1", "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
@@ -0,0 +1,109 @@
+// Pluggable storage of attachment contents.
+package controller
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"awesomeProject/errors"
+)
+
+// Blob is the content of a stored attachment, seekable so it can be served in ranges.
+type Blob interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// BlobStore keeps attachment contents under keys made of letters, digits, '-' and '/'.
+// The local filesystem implements it; an S3-compatible bucket fits the same methods, with Open
+// answering reads through ranged GETs.
+type BlobStore interface {
+	// Put stores the content read from r under key and returns its size.
+	Put(key string, r io.Reader) (int64, error)
+	Open(key string) (Blob, error)
+	// Delete removes the content of key, a missing key is not an error.
+	Delete(key string) error
+}
+
+// newBlobStore stores attachments under the BLOB_DIR env variable, ./data/blobs by default.
+func newBlobStore() BlobStore {
+	dir := os.Getenv("BLOB_DIR")
+	if dir == "" {
+		dir = filepath.Join("data", "blobs")
+	}
+	return NewLocalBlobStore(dir)
+}
+
+// LocalBlobStore keeps blobs as files below a directory.
+type LocalBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore returns a store writing below dir, which is created on first use.
+func NewLocalBlobStore(dir string) *LocalBlobStore {
+	return &LocalBlobStore{dir: dir}
+}
+
+// path returns the file of key, rejecting keys that would leave the directory.
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "..") || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return "", errors.New("Error: Invalid blob key, " + key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the content to a temporary file first so a failed upload never leaves a partial blob.
+func (s *LocalBlobStore) Put(key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, errors.New("Error: creating the blob directory, " + err.Error())
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return 0, errors.New("Error: creating the blob, " + err.Error())
+	}
+	size, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, errors.New("Error: writing the blob, " + err.Error())
+	}
+	return size, nil
+}
+
+func (s *LocalBlobStore) Open(key string) (Blob, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, errors.New("Error: Failed to retrive the blob, not found")
+	}
+	if err != nil {
+		return nil, errors.New("Error: Failed to retrive the blob, " + err.Error())
+	}
+	return f, nil
+}
+
+func (s *LocalBlobStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.New("Error: removing the blob, " + err.Error())
+	}
+	return nil
+}
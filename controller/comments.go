@@ -0,0 +1,278 @@
+// Threaded comments on articles with moderation.
+package controller
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject/errors"
+	"github.com/gorilla/mux"
+)
+
+const (
+	commentPending  = "pending"
+	commentApproved = "approved"
+	commentRejected = "rejected"
+
+	defaultCommentLimit = 20
+	maxCommentLimit     = 100
+	maxCommentLen       = 10000
+)
+
+// preModeration keeps new comments pending until an editor approves them, set with the
+// COMMENT_MODERATION env variable to "pre"; otherwise comments are approved when posted.
+var preModeration = os.Getenv("COMMENT_MODERATION") == "pre"
+
+// commentParam loads the comment of the url, which has to belong to the article.
+func (h *Handler) commentParam(w http.ResponseWriter, r *http.Request, article Article) (Comment, bool) {
+	commentID, err := strconv.Atoi(mux.Vars(r)["cid"])
+	if err != nil {
+		http.Error(w, "Error: getting the comment ID.", http.StatusUnprocessableEntity)
+		return Comment{}, false
+	}
+	comment, err := h.database.GetComment(commentID)
+	if err == nil && (comment.ArticleID != article.ID || comment.Deleted) {
+		err = errors.New("Error: Failed to retrive the comment with ID, not found")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return Comment{}, false
+	}
+	return comment, true
+}
+
+// readCommentBody decodes the comment body of the request.
+func readCommentBody(r *http.Request) (Comment, error) {
+	var comment Comment
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err == nil {
+		err = decodeBody(r, body, &comment)
+	}
+	if err != nil {
+		return comment, errors.New("Error: Unmarshalling comment, " + err.Error())
+	}
+	comment.Body = strings.TrimSpace(comment.Body)
+	if comment.Body == "" || len(comment.Body) > maxCommentLen {
+		return comment, errors.New("Error: The comment 'body' is required, up to " + strconv.Itoa(maxCommentLen) + " bytes.")
+	}
+	return comment, nil
+}
+
+// canSeeComment reports whether the caller of the request sees the comment: approved comments are
+// public, others are only shown to their user and to editors.
+func canSeeComment(r *http.Request, c Comment) bool {
+	return c.Status == commentApproved || c.User == currentUser(r) || hasRole(r, editorRole)
+}
+
+// commentThreads nests the replies under their comments. Hidden comments are left out with their
+// replies, deleted ones are kept without body only while they have replies.
+func commentThreads(comments []Comment, visible func(Comment) bool) []CommentThread {
+	children := make(map[int][]Comment)
+	for _, c := range comments {
+		children[c.ParentID] = append(children[c.ParentID], c)
+	}
+
+	var build func(parent int) []CommentThread
+	build = func(parent int) []CommentThread {
+		threads := []CommentThread{}
+		for _, c := range children[parent] {
+			if !visible(c) {
+				continue
+			}
+			thread := CommentThread{Comment: c, Replies: build(c.ID)}
+			if c.Deleted {
+				if len(thread.Replies) == 0 {
+					continue
+				}
+				thread.Body = ""
+			}
+			threads = append(threads, thread)
+		}
+		return threads
+	}
+	return build(0)
+}
+
+// commentCounts returns the number of approved comments of the articles by id, a failure is only
+// logged as the articles are answered all the same.
+func (h *Handler) commentCounts(articleIDs ...int) map[int]int {
+	counts, err := h.database.CountComments(articleIDs...)
+	if err != nil {
+		log.Println(err.Error())
+	}
+	return counts
+}
+
+// withCommentCount returns the article with its number of approved comments filled in.
+func (h *Handler) withCommentCount(a Article) Article {
+	a.CommentCount = h.commentCounts(a.ID)[a.ID]
+	return a
+}
+
+// ListComments returns a page of the comment threads of article 'id', oldest first - GET METHOD.
+// 'page' starts at 1, 'limit' is the number of top level comments per page, replies are always included.
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	params := r.URL.Query()
+	page, err := intParam(params.Get("page"), 1)
+	if err != nil || page <= 0 {
+		http.Error(w, "Error: Invalid page.", http.StatusUnprocessableEntity)
+		return
+	}
+	limit, err := intParam(params.Get("limit"), defaultCommentLimit)
+	if err != nil || limit <= 0 || limit > maxCommentLimit {
+		http.Error(w, "Error: Invalid limit, expected 1 to "+strconv.Itoa(maxCommentLimit)+".", http.StatusUnprocessableEntity)
+		return
+	}
+
+	comments, err := h.database.ListComments(article.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	threads := commentThreads(comments, func(c Comment) bool { return canSeeComment(r, c) })
+
+	result := CommentPage{ArticleID: article.ID, Total: len(threads), Page: page, Limit: limit, Comments: []CommentThread{}}
+	if start := (page - 1) * limit; start < len(threads) {
+		end := start + limit
+		if end > len(threads) {
+			end = len(threads)
+		}
+		result.Comments = threads[start:end]
+	}
+	writeJson(w, result)
+}
+
+// AddComment posts a comment on article 'id', a reply when 'parent_id' is given - POST METHOD.
+func (h *Handler) AddComment(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	input, err := readCommentBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if input.ParentID != 0 {
+		parent, err := h.database.GetComment(input.ParentID)
+		if err != nil || parent.ArticleID != article.ID || parent.Deleted || !canSeeComment(r, parent) {
+			http.Error(w, "Error: The parent comment does not exist on this article.", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	comment := Comment{
+		ArticleID: article.ID,
+		ParentID:  input.ParentID,
+		User:      currentUser(r),
+		Body:      input.Body,
+		Status:    commentApproved,
+		CreatedAt: time.Now().UTC(),
+	}
+	if preModeration {
+		comment.Status = commentPending
+	}
+	comment, err = h.database.AddComment(comment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	writeJsonStatus(w, http.StatusCreated, comment)
+}
+
+// UpdateComment replaces the body of comment 'cid', only by its user - PUT METHOD.
+// An edited comment waits for approval again under pre-moderation.
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	comment, ok := h.commentParam(w, r, article)
+	if !ok {
+		return
+	}
+	if comment.User != currentUser(r) {
+		http.Error(w, "Error: Only the user who posted the comment can edit it.", http.StatusForbidden)
+		return
+	}
+	input, err := readCommentBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	now := time.Now().UTC()
+	comment.Body, comment.UpdatedAt = input.Body, &now
+	if preModeration && comment.Status == commentApproved {
+		comment.Status = commentPending
+	}
+	if err := h.database.UpdateComment(comment); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	writeJson(w, comment)
+}
+
+// DeleteComment deletes comment 'cid', by its user or an editor - DELETE METHOD.
+// Its replies stay in the thread.
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	comment, ok := h.commentParam(w, r, article)
+	if !ok {
+		return
+	}
+	if comment.User != currentUser(r) && !hasRole(r, editorRole) {
+		http.Error(w, "Error: Only the user who posted the comment can delete it.", http.StatusForbidden)
+		return
+	}
+
+	now := time.Now().UTC()
+	comment.Body, comment.Deleted, comment.UpdatedAt = "", true, &now
+	if err := h.database.UpdateComment(comment); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ModerateComment approves or rejects comment 'cid' - POST METHOD.
+func (h *Handler) ModerateComment(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.readableArticle(w, r)
+	if !ok {
+		return
+	}
+	comment, ok := h.commentParam(w, r, article)
+	if !ok {
+		return
+	}
+
+	comment.Status = commentApproved
+	if mux.Vars(r)["action"] == "reject" {
+		comment.Status = commentRejected
+	}
+	if err := h.database.UpdateComment(comment); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	writeJson(w, comment)
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentThreads(t *testing.T) {
+	comments := []Comment{
+		{ID: 1, Body: "a", Status: commentApproved},
+		{ID: 2, ParentID: 1, Body: "b", Status: commentApproved},
+		{ID: 3, Body: "c", Status: commentApproved, Deleted: true},
+		{ID: 4, Body: "d", Status: commentApproved, Deleted: true},
+		{ID: 5, ParentID: 4, Body: "e", Status: commentApproved},
+		{ID: 6, ParentID: 2, Body: "f", Status: commentRejected},
+	}
+	threads := commentThreads(comments, func(c Comment) bool { return c.Status == commentApproved })
+
+	assert.Equal(t, 2, len(threads))
+	assert.Equal(t, 1, threads[0].ID)
+	assert.Equal(t, 2, threads[0].Replies[0].ID)
+	assert.Equal(t, 0, len(threads[0].Replies[0].Replies))
+	// a deleted comment stays without its body while it has replies.
+	assert.Equal(t, 4, threads[1].ID)
+	assert.Equal(t, "", threads[1].Body)
+	assert.Equal(t, 5, threads[1].Replies[0].ID)
+}
+
+func TestHandler_Comments(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}})
+	accounts["reader"] = account{password: "password"}
+	defer delete(accounts, "reader")
+
+	rr := serveAs(h, "reader", "POST", "http://localhost:8984/articles/1/comments", `{"body":"Nice one."}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/comments", `{"body":"Thanks!","parent_id":1}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/comments", `{"body":"Second."}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/comments", `{"body":"x","parent_id":9}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1", "")
+	var article Article
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, 3, article.CommentCount)
+
+	rr = serveAs(h, "reader", "GET", "http://localhost:8984/articles/1/comments?limit=1", "")
+	var page CommentPage
+	json.Unmarshal(rr.Body.Bytes(), &page)
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, 1, len(page.Comments))
+	assert.Equal(t, "Thanks!", page.Comments[0].Replies[0].Body)
+	rr = serveAs(h, "reader", "GET", "http://localhost:8984/articles/1/comments?limit=1&page=2", "")
+	json.Unmarshal(rr.Body.Bytes(), &page)
+	assert.Equal(t, 3, page.Comments[0].ID)
+
+	rr = serveAs(h, "reader", "PUT", "http://localhost:8984/articles/1/comments/2", `{"body":"mine now"}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "reader", "PUT", "http://localhost:8984/articles/1/comments/1", `{"body":"Very nice one."}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/comments/3/reject", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveAs(h, "reader", "POST", "http://localhost:8984/articles/1/comments/1/reject", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "reader", "DELETE", "http://localhost:8984/articles/1/comments/1", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = serveAs(h, "reader", "GET", "http://localhost:8984/articles/1/comments", "")
+	json.Unmarshal(rr.Body.Bytes(), &page)
+	assert.Equal(t, 1, page.Total)
+	assert.True(t, page.Comments[0].Deleted)
+	counts, _ := h.database.CountComments(1)
+	assert.Equal(t, map[int]int{1: 1}, counts)
+
+	// every article answered has its count, none answered is 0 rather than left out.
+	rr = serve(h, "GET", "http://localhost:8984/search?q=first", "")
+	var result SearchResult
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, 1, result.Results[0].CommentCount)
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"One","date":"2018-10-04","body":"changed","tags":["aaa"]}`)
+	assert.Contains(t, rr.Body.String(), `"comment_count":1`)
+	rr = serve(h, "POST", "http://localhost:8984/articles", `{"title":"Two","date":"2018-10-05","body":"second","tags":["aaa"]}`)
+	assert.Contains(t, rr.Body.String(), `"comment_count":0`)
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"time"
 
 	"awesomeProject/errors"
 	"gopkg.in/mgo.v2"
@@ -13,29 +14,101 @@ import (
 )
 
 type Database struct {
-	mutex sync.Mutex
-
-	articlesID int
+	// indexes are created once per process on first use.
+	textIndexOnce sync.Once
+	hashIndexOnce sync.Once
+	keysIndexOnce sync.Once
+	userIndexOnce sync.Once
+	slugIndexOnce sync.Once
 }
 
+// notDeleted matches the articles not moved to trash.
+var notDeleted = bson.M{"$exists": false}
+
 const (
 	DBNAME     = "ffdatabase"
 	COLLECTION = "NewArtStore"
+
+	IDEMPOTENCY_COLLECTION = "IdempotencyKeys"
+	REVISION_COLLECTION    = "Revisions"
+	AUDIT_COLLECTION       = "AuditLog"
+	AUTHOR_COLLECTION      = "Authors"
+	COMMENT_COLLECTION     = "Comments"
+	ATTACHMENT_COLLECTION  = "Attachments"
+	// COUNTER_COLLECTION holds the last number handed out by every sequence, see nextSequence.
+	COUNTER_COLLECTION = "Counters"
 )
 
-// checkDuplicate checks if record provided by user already exists in database.
-func checkDuplicate(data Article, db *mgo.Collection) (bool, int, error) {
+// checkDuplicate returns a *DuplicateError when an article other than 'exclude' with the same
+// content hash, or a simhash within duplicateThreshold, already exists in database.
+func checkDuplicate(data Article, db *mgo.Collection, exclude int) error {
 	r := Article{}
-	pipeline := []bson.M{{"$match": bson.M{"date": data.Date}}, {"$match": bson.M{"title": data.Title}}, {"$match": bson.M{"body": data.Body}}, {"$match": bson.M{"tags": bson.M{"$in": data.Tags}}}}
-	err := db.Pipe(pipeline).One(&r)
-	if err != nil && strings.Contains(err.Error(), "not found") {
-		return false, -1, err
+	others := bson.M{"$ne": exclude}
+	err := db.Find(bson.M{"content_hash": data.ContentHash, "_id": others}).Select(bson.M{"_id": 1}).One(&r)
+	if err == nil {
+		return &DuplicateError{ID: r.ID}
+	}
+	if err != mgo.ErrNotFound {
+		return errors.New(fmt.Sprintf("Error: Failed to check for duplicate articles, %v", err))
+	}
+	if data.SimHash == 0 || duplicateThreshold < 0 {
+		return nil
+	}
+
+	// within fewer bits than there are bands, see bandThreshold, near-duplicates share a band and only
+	// those are read through the index.
+	candidates := bson.M{"simhash_bands": bson.M{"$in": data.SimHashBands}, "_id": others}
+	iter := db.Find(candidates).Select(bson.M{"simhash": 1}).Sort("_id").Iter()
+	for iter.Next(&r) {
+		if distance, near := isNearDuplicate(data.SimHash, r.SimHash); near {
+			iter.Close()
+			return &DuplicateError{ID: r.ID, Near: true, Distance: distance}
+		}
 	}
-	return true, r.ID, err
+	if err := iter.Close(); err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to check for duplicate articles, %v", err))
+	}
+	return nil
+}
+
+// ensureHashIndex fingerprints the articles stored before content hashes or simhash bands existed
+// and creates the unique index on content_hash and the one on simhash_bands, once per process.
+func (d *Database) ensureHashIndex(db *mgo.Collection) {
+	d.hashIndexOnce.Do(func() {
+		var a Article
+		unfingerprinted := bson.M{"$or": []bson.M{
+			{"content_hash": bson.M{"$exists": false}},
+			{"simhash": bson.M{"$exists": true}, "simhash_bands": bson.M{"$exists": false}},
+		}, "deleted_at": notDeleted}
+		iter := db.Find(unfingerprinted).Iter()
+		for iter.Next(&a) {
+			fingerprint(&a)
+			update := bson.M{"content_hash": a.ContentHash}
+			if a.SimHash != 0 {
+				update["simhash"] = a.SimHash
+				update["simhash_bands"] = a.SimHashBands
+			}
+			if err := db.UpdateId(a.ID, bson.M{"$set": update}); err != nil {
+				log.Println("Error: fingerprinting article", a.ID, err)
+			}
+		}
+		if err := iter.Close(); err != nil {
+			log.Println("Error: fingerprinting articles, ", err)
+		}
+
+		// existing duplicates make this fail, checkDuplicate still finds them by query.
+		err := db.EnsureIndex(mgo.Index{Key: []string{"content_hash"}, Unique: true, Sparse: true, Name: "article_content_hash"})
+		if err != nil {
+			log.Println("Error: creating the unique content_hash index, ", err)
+		}
+		if err := db.EnsureIndex(mgo.Index{Key: []string{"simhash_bands"}, Name: "article_simhash_bands"}); err != nil {
+			log.Println("Error: creating the simhash_bands index, ", err)
+		}
+	})
 }
 
 // AddArticles insert the record into datbase - POST METHOD.
-func (d Database) AddArticle(data Article) (int, error) {
+func (d *Database) AddArticle(data Article) (int, error) {
 	session, err := mgo.Dial("localhost:27017")
 	if err != nil {
 		return -1, errors.New(fmt.Sprintf("Error: Failed to establish connection to mongoDB server, %v", err))
@@ -44,38 +117,126 @@ func (d Database) AddArticle(data Article) (int, error) {
 
 	session.SetSafe(&mgo.Safe{})
 	db := session.DB(DBNAME).C(COLLECTION)
+	d.ensureHashIndex(db)
 
 	// first verify if the entry provided is duplicate.
-	isExists, id, err := checkDuplicate(data, db)
-	if isExists {
-		d.articlesID = id
-		//no need get the err here - it is nil if isExists is true.
-		return -1, errors.New(fmt.Sprintf("Info: Article already exists in database, %d", id))
+	fingerprint(&data)
+	describe(&data)
+	if err := checkDuplicate(data, db, 0); err != nil {
+		return -1, err
 	}
 
-	numRec, err := db.Count()
-	log.Println("numRec:", numRec)
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
-	if err != nil {
-		log.Println("Error: Unable to get number of records exists from Database, ", err)
-		d.articlesID += 1
-		data.ID = d.articlesID
-	} else {
-		d.articlesID = numRec + 1
-		data.ID = d.articlesID
+	// numbers of purged articles are not handed out again.
+	if data.ID, err = nextSequence(session, "articles", db, bson.M{}, "_id"); err != nil {
+		return -1, err
 	}
+	d.ensureSlugIndex(db)
 
-	err = db.Insert(data)
+	// the slug is checked before inserting, the unique index catches one taken meanwhile.
+	for retry := 0; ; retry++ {
+		if err := assignSlug(&data, Article{}, slugTaken(db, 0)); err != nil {
+			return -1, err
+		}
+		err = db.Insert(data)
+		if !slugConflict(err) || retry == slugRetries {
+			break
+		}
+	}
+	if mgo.IsDup(err) && !slugConflict(err) {
+		// the same content was inserted concurrently, report that article.
+		if dupErr := checkDuplicate(data, db, 0); dupErr != nil {
+			return -1, dupErr
+		}
+	}
 	if err != nil {
 		return -1, errors.New(fmt.Sprintf("Error: adding the article, %v", err))
 	}
-	log.Println("Added new Article with id :", d.articlesID)
-	return d.articlesID, nil
+	log.Println("Added new Article with id :", data.ID)
+	return data.ID, nil
+}
+
+// slugQuery selects the articles having or having had 'slug'.
+func slugQuery(slug string) bson.M {
+	return bson.M{"$or": []bson.M{{"slug": slug}, {"old_slugs": slug}}}
+}
+
+// slugTaken reports whether an article other than 'id' has or had a slug, those in trash included.
+func slugTaken(db *mgo.Collection, id int) func(string) (bool, error) {
+	return func(slug string) (bool, error) {
+		query := slugQuery(slug)
+		query["_id"] = bson.M{"$ne": id}
+		n, err := db.Find(query).Count()
+		if err != nil {
+			return false, errors.New(fmt.Sprintf("Error: Failed to check the slug, %v", err))
+		}
+		return n > 0, nil
+	}
+}
+
+// slugIndex is unique over the current slugs, the former ones only lead to an article.
+const slugIndex = "article_slug_unique"
+
+// slugRetries bounds the attempts at the next slug when another article took it meanwhile.
+const slugRetries = 5
+
+// slugConflict reports whether err is the unique slug index refusing a slug.
+func slugConflict(err error) bool {
+	return mgo.IsDup(err) && strings.Contains(err.Error(), slugIndex)
+}
+
+// ensureSlugIndex gives a slug to the articles stored before slugs existed and indexes them, once per process.
+func (d *Database) ensureSlugIndex(db *mgo.Collection) {
+	d.slugIndexOnce.Do(func() {
+		var a Article
+		iter := db.Find(bson.M{"slug": bson.M{"$exists": false}}).Sort("_id").Iter()
+		for iter.Next(&a) {
+			if err := assignSlug(&a, Article{}, slugTaken(db, a.ID)); err != nil {
+				log.Println("Error: assigning a slug to article", a.ID, err)
+				continue
+			}
+			if err := db.UpdateId(a.ID, bson.M{"$set": bson.M{"slug": a.Slug}}); err != nil {
+				log.Println("Error: assigning a slug to article", a.ID, err)
+			}
+		}
+		if err := iter.Close(); err != nil {
+			log.Println("Error: assigning slugs to articles, ", err)
+		}
+
+		// the slug index was not unique before, it is replaced.
+		if err := db.DropIndexName("article_slug"); err != nil && !strings.Contains(err.Error(), "not found") {
+			log.Println("Error: dropping the former article slug index, ", err)
+		}
+		if err := db.EnsureIndex(mgo.Index{Key: []string{"slug"}, Unique: true, Sparse: true, Name: slugIndex}); err != nil {
+			log.Println("Error: creating the article slug index, ", err)
+		}
+		if err := db.EnsureIndex(mgo.Index{Key: []string{"old_slugs"}, Sparse: true, Name: "article_old_slugs"}); err != nil {
+			log.Println("Error: creating the article old_slugs index, ", err)
+		}
+	})
+}
+
+// GetArticleBySlug retrives the article having 'slug' now or before.
+func (d *Database) GetArticleBySlug(slug string) (Article, error) {
+	session, err := dial()
+	if err != nil {
+		return Article{}, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+	d.ensureSlugIndex(db)
+
+	query := slugQuery(slug)
+	query["deleted_at"] = notDeleted
+	result := Article{}
+	if err := db.Find(query).Sort("_id").One(&result); err != nil {
+		return result, errors.New(fmt.Sprintf("Error: Failed to retrive the article with slug, %v", err))
+	}
+	return result, nil
 }
 
 // GetArticleByID retrives the article with 'id' specified by user from datbase - GET METHOD.
-func (d Database) GetArticleByID(id int) (Article, error) {
+func (d *Database) GetArticleByID(id int) (Article, error) {
 	session, err := mgo.Dial("localhost:27017")
 	if err != nil {
 		return Article{}, errors.New(fmt.Sprintf("Error: Failed to establish connection to mongoDB server, %v", err))
@@ -86,7 +247,7 @@ func (d Database) GetArticleByID(id int) (Article, error) {
 
 	result := Article{}
 
-	if err := db.FindId(id).One(&result); err != nil {
+	if err := db.Find(bson.M{"_id": id, "deleted_at": notDeleted}).One(&result); err != nil {
 		return result, errors.New(fmt.Sprintf("Error: Failed to retrive the article with ID, %v", err))
 	}
 
@@ -95,7 +256,7 @@ func (d Database) GetArticleByID(id int) (Article, error) {
 }
 
 // GetArticleByTagDate retrieves array of Articles that matches the 'tag' and 'date' provided by user - GET METHOD.
-func (d Database) GetArticleByTagDate(tagStr, dateStr string) (ArticlesArr, error) {
+func (d *Database) GetArticleByTagDate(tagStr, dateStr string) (ArticlesArr, error) {
 	session, err := mgo.Dial("localhost:27017")
 	if err != nil {
 		return ArticlesArr{}, errors.New(fmt.Sprintf("Error: Failed to establish connection to mongoDB server, %v", err))
@@ -108,7 +269,7 @@ func (d Database) GetArticleByTagDate(tagStr, dateStr string) (ArticlesArr, erro
 
 	tagArr := []string{tagStr}
 
-	pipeline := []bson.M{{"$match": bson.M{"date": dateStr}}, {"$match": bson.M{"tags": bson.M{"$in": tagArr}}}}
+	pipeline := []bson.M{{"$match": bson.M{"date": dateStr}}, {"$match": bson.M{"tags": bson.M{"$in": tagArr}}}, {"$match": bson.M{"deleted_at": notDeleted}}}
 	log.Println(pipeline)
 	err = db.Pipe(pipeline).All(&result)
 	if err != nil || len(result) == 0 {
@@ -120,27 +281,986 @@ func (d Database) GetArticleByTagDate(tagStr, dateStr string) (ArticlesArr, erro
 }
 
 // DeleteArticle deletes article entry from database
-func (d Database) DeleteArticle(data Article) (bool, error) {
+func (d *Database) DeleteArticle(data Article) (Article, error) {
 	session, err := mgo.Dial("localhost:27017")
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("Error: Failed to establish connection to mongoDB server, %v", err))
+		return Article{}, errors.New(fmt.Sprintf("Error: Failed to establish connection to mongoDB server, %v", err))
 	}
 	defer session.Close()
 
 	session.SetSafe(&mgo.Safe{})
 	db := session.DB(DBNAME).C(COLLECTION)
 
-	// first verify if the entry provided is duplicate.
-	isExists, id, err := checkDuplicate(data, db)
-	if !isExists {
-		return false, errors.New(fmt.Sprintf("Error: Data enter not found in database, %v", err))
-	}
+	d.ensureHashIndex(db)
 
-	err = db.RemoveId(id)
+	// the article to delete is the one with the same content.
+	fingerprint(&data)
+	r := Article{}
+	_, err = db.Find(bson.M{"content_hash": data.ContentHash}).Apply(mgo.Change{Update: trashUpdate()}, &r)
+	if err == mgo.ErrNotFound {
+		return r, errors.New(fmt.Sprintf("Error: Data enter not found in database, %v", err))
+	}
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("Error: removing the article, %v", err))
+		return r, errors.New(fmt.Sprintf("Error: removing the article, %v", err))
 	}
 	// reach here if deleted the entry successfully
-	log.Println("Successfully removed the article with id: ", id)
-	return true, nil
+	log.Println("Successfully moved to trash the article with id: ", r.ID)
+	return r, nil
+}
+
+// trashUpdate marks an article as deleted now; its fingerprints are dropped so the same content
+// can be added again, they are computed again on restore.
+func trashUpdate() bson.M {
+	return bson.M{
+		"$set":   bson.M{"deleted_at": time.Now().UTC()},
+		"$unset": bson.M{"content_hash": "", "simhash": "", "simhash_bands": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+}
+
+// contentUpdate returns the update document writing the content fields of data.
+func contentUpdate(data Article) bson.M {
+	set := bson.M{
+		"title":        data.Title,
+		"date":         data.Date,
+		"body":         data.Body,
+		"tags":         data.Tags,
+		"content_hash": data.ContentHash,
+		"word_count":   data.WordCount,
+		"reading_time": data.ReadingTime,
+		"excerpt":      data.Excerpt,
+	}
+	unset := bson.M{}
+	update := bson.M{"$set": set}
+	if data.SimHash != 0 {
+		set["simhash"], set["simhash_bands"] = data.SimHash, data.SimHashBands
+	} else {
+		unset["simhash"], unset["simhash_bands"] = "", ""
+	}
+	if data.Format != "" {
+		set["format"] = data.Format
+	} else {
+		unset["format"] = ""
+	}
+	if data.FirstImage != "" {
+		set["first_image"] = data.FirstImage
+	} else {
+		unset["first_image"] = ""
+	}
+	if data.Slug != "" {
+		set["slug"] = data.Slug
+	}
+	if len(data.OldSlugs) > 0 {
+		set["old_slugs"] = data.OldSlugs
+	} else {
+		unset["old_slugs"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// UpdateArticle replaces the content of the article with data.ID.
+func (d *Database) UpdateArticle(data Article) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+	d.ensureHashIndex(db)
+
+	fingerprint(&data)
+	describe(&data)
+	if err := checkDuplicate(data, db, data.ID); err != nil {
+		return err
+	}
+
+	// the slug changes with the title of the article as stored, a missing article is reported below.
+	d.ensureSlugIndex(db)
+	var current Article
+	if err := db.FindId(data.ID).One(&current); err != nil && err != mgo.ErrNotFound {
+		return errors.New(fmt.Sprintf("Error: Failed to update the article with ID, %v", err))
+	}
+	for retry := 0; ; retry++ {
+		if err := assignSlug(&data, current, slugTaken(db, data.ID)); err != nil {
+			return err
+		}
+		update := contentUpdate(data)
+		update["$inc"] = bson.M{"version": 1}
+		err = db.Update(versionQuery(data.ID, data.Version), update)
+		if !slugConflict(err) || retry == slugRetries {
+			break
+		}
+	}
+	if mgo.IsDup(err) && !slugConflict(err) {
+		if dupErr := checkDuplicate(data, db, data.ID); dupErr != nil {
+			return dupErr
+		}
+	}
+	if err == mgo.ErrNotFound {
+		return versionMismatch(db, data.ID, "Error: Failed to update the article with ID, %v")
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to update the article with ID, %v", err))
+	}
+	log.Println("Updated the article with id :", data.ID)
+	return nil
+}
+
+// versionQuery selects the article only while it is at 'version' and not in trash, articles never
+// updated have no version.
+func versionQuery(id, version int) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, "deleted_at": notDeleted, "version": bson.M{"$in": []interface{}{0, nil}}}
+	}
+	return bson.M{"_id": id, "deleted_at": notDeleted, "version": version}
+}
+
+// versionMismatch tells a missing article from one at another version after a versionQuery found nothing.
+func versionMismatch(db *mgo.Collection, id int, format string) error {
+	count, err := db.Find(bson.M{"_id": id, "deleted_at": notDeleted}).Count()
+	if err != nil {
+		return errors.New(fmt.Sprintf(format, err))
+	}
+	if count > 0 {
+		return errVersionConflict
+	}
+	return errors.New(fmt.Sprintf(format, mgo.ErrNotFound))
+}
+
+// DeleteArticleByID moves the article with 'id' at 'version' to trash.
+func (d *Database) DeleteArticleByID(id, version int) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+
+	err = db.Update(versionQuery(id, version), trashUpdate())
+	if err == mgo.ErrNotFound {
+		return versionMismatch(db, id, "Error: Failed to retrive the article with ID, %v")
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: removing the article, %v", err))
+	}
+	log.Println("Successfully moved to trash the article with id: ", id)
+	return nil
+}
+
+// UpdateStatus sets the status of the article with 'id' at 'version'.
+func (d *Database) UpdateStatus(id, version int, status string) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+
+	update := bson.M{"$set": bson.M{"status": status}, "$inc": bson.M{"version": 1}}
+	if status == statusPublished {
+		update["$set"] = bson.M{"status": status, "published_at": time.Now().UTC()}
+		update["$unset"] = bson.M{"publish_at": ""}
+	}
+	err = db.Update(versionQuery(id, version), update)
+	if err == mgo.ErrNotFound {
+		return versionMismatch(db, id, "Error: Failed to update the article with ID, %v")
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to update the article with ID, %v", err))
+	}
+	log.Println("Article", id, "is now", status)
+	return nil
+}
+
+// SchedulePublish sets or clears the publish_at time of the article with 'id' at 'version'.
+func (d *Database) SchedulePublish(id, version int, at *time.Time) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+
+	update := bson.M{"$unset": bson.M{"publish_at": ""}, "$inc": bson.M{"version": 1}}
+	if at != nil {
+		update = bson.M{"$set": bson.M{"publish_at": at.UTC()}, "$inc": bson.M{"version": 1}}
+	}
+	err = db.Update(versionQuery(id, version), update)
+	if err == mgo.ErrNotFound {
+		return versionMismatch(db, id, "Error: Failed to update the article with ID, %v")
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to update the article with ID, %v", err))
+	}
+	return nil
+}
+
+// RestoreArticle takes the article with 'id' out of trash, unless its content was added again meanwhile.
+func (d *Database) RestoreArticle(id int) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+	trashed := bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}}
+
+	var data Article
+	if err := db.Find(trashed).One(&data); err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to retrive the article from trash, %v", err))
+	}
+	fingerprint(&data)
+	describe(&data)
+	if err := checkDuplicate(data, db, id); err != nil {
+		return err
+	}
+
+	update := contentUpdate(data)
+	unset, ok := update["$unset"].(bson.M)
+	if !ok {
+		unset = bson.M{}
+	}
+	unset["deleted_at"] = ""
+	update["$unset"] = unset
+	update["$inc"] = bson.M{"version": 1}
+	err = db.Update(trashed, update)
+	if mgo.IsDup(err) {
+		if dupErr := checkDuplicate(data, db, id); dupErr != nil {
+			return dupErr
+		}
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to restore the article, %v", err))
+	}
+	log.Println("Restored from trash the article with id: ", id)
+	return nil
+}
+
+// PurgeArticle removes the article with 'id' from trash together with its revisions, for good.
+func (d *Database) PurgeArticle(id int) (Article, error) {
+	session, err := dial()
+	if err != nil {
+		return Article{}, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+
+	var purged Article
+	_, err = db.Find(bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}}).Apply(mgo.Change{Remove: true}, &purged)
+	if err != nil {
+		return purged, errors.New(fmt.Sprintf("Error: Failed to retrive the article from trash, %v", err))
+	}
+	if _, err := session.DB(DBNAME).C(REVISION_COLLECTION).RemoveAll(bson.M{"article_id": id}); err != nil {
+		return purged, errors.New(fmt.Sprintf("Error: removing the revisions of the article, %v", err))
+	}
+	if _, err := session.DB(DBNAME).C(COMMENT_COLLECTION).RemoveAll(bson.M{"article_id": id}); err != nil {
+		return purged, errors.New(fmt.Sprintf("Error: removing the comments of the article, %v", err))
+	}
+	log.Println("Purged the article with id: ", id)
+	return purged, nil
+}
+
+// ensureTextIndex creates the text index on title and body used by Search.
+func (d *Database) ensureTextIndex(db *mgo.Collection) error {
+	var err error
+	d.textIndexOnce.Do(func() {
+		err = db.EnsureIndex(mgo.Index{
+			Key:     []string{"$text:title", "$text:body"},
+			Weights: map[string]int{"title": titleWeight, "body": bodyWeight},
+			Name:    "article_text",
+		})
+	})
+	return err
+}
+
+// Search runs the query with $text and returns the matches sorted by text score - GET METHOD.
+func (d *Database) Search(query SearchQuery) ([]SearchHit, error) {
+	session, err := mgo.Dial("localhost:27017")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to establish connection to mongoDB server, %v", err))
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+	if err := d.ensureTextIndex(db); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to create the text index, %v", err))
+	}
+
+	// mongo understands "quoted phrases" in $search itself.
+	filter := bson.M{"$text": bson.M{"$search": query.Text}, "deleted_at": notDeleted}
+	if query.Published {
+		filter["status"] = publishedStatus
+	}
+	if len(query.Tags) > 0 {
+		filter["tags"] = bson.M{"$in": query.Tags}
+	}
+	dateRange := bson.M{}
+	if query.From != "" {
+		dateRange["$gte"] = query.From
+	}
+	if query.To != "" {
+		dateRange["$lte"] = query.To
+	}
+	if len(dateRange) > 0 {
+		filter["date"] = dateRange
+	}
+
+	var hits []SearchHit
+	q := db.Find(filter).Select(bson.M{"score": bson.M{"$meta": "textScore"}}).Sort("$textScore:score")
+	if query.Limit > 0 {
+		q = q.Limit(query.Limit)
+	}
+	if err := q.All(&hits); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to search the articles, %v", err))
+	}
+	return hits, nil
+}
+
+// dial opens a session on the mongo server.
+func dial() (*mgo.Session, error) {
+	session, err := mgo.Dial("localhost:27017")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to establish connection to mongoDB server, %v", err))
+	}
+	session.SetSafe(&mgo.Safe{})
+	return session, nil
+}
+
+// publishedStatus matches published articles, including those stored before the workflow existed.
+var publishedStatus = bson.M{"$in": []interface{}{statusPublished, "", nil}}
+
+// nextSequence returns the next number of sequence 'name', its counter document is increased atomically
+// so concurrent callers never get the same one and numbers of removed documents are not handed out again.
+// A missing counter, as for data stored before counters, starts at the highest 'field' of the documents
+// of c matching query.
+func nextSequence(session *mgo.Session, name string, c *mgo.Collection, query bson.M, field string) (int, error) {
+	counters := session.DB(DBNAME).C(COUNTER_COLLECTION)
+	increment := mgo.Change{Update: bson.M{"$inc": bson.M{"seq": 1}}, ReturnNew: true}
+
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	_, err := counters.FindId(name).Apply(increment, &counter)
+	if err == mgo.ErrNotFound {
+		last := bson.M{}
+		if err = c.Find(query).Select(bson.M{field: 1}).Sort("-" + field).One(&last); err != nil && err != mgo.ErrNotFound {
+			return 0, errors.New(fmt.Sprintf("Error: Failed to start the counter %s, %v", name, err))
+		}
+		start, _ := last[field].(int)
+		// a concurrent caller may have started it already.
+		if err = counters.Insert(bson.M{"_id": name, "seq": start}); err != nil && !mgo.IsDup(err) {
+			return 0, errors.New(fmt.Sprintf("Error: Failed to start the counter %s, %v", name, err))
+		}
+		_, err = counters.FindId(name).Apply(increment, &counter)
+	}
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Error: Failed to increase the counter %s, %v", name, err))
+	}
+	return counter.Seq, nil
+}
+
+// filterQuery translates filter to a mongo query document.
+func filterQuery(filter ArticleFilter) bson.M {
+	query := bson.M{"deleted_at": notDeleted}
+	if filter.Deleted {
+		query["deleted_at"] = bson.M{"$exists": true}
+	}
+	if filter.Scheduled {
+		query["publish_at"] = bson.M{"$exists": true}
+	}
+	if filter.Published {
+		query["status"] = publishedStatus
+	}
+	if filter.Tag != "" {
+		query["tags"] = filter.Tag
+	}
+	if filter.AuthorID != 0 {
+		query["author_id"] = filter.AuthorID
+	}
+	dateRange := bson.M{}
+	if filter.From != "" {
+		dateRange["$gte"] = filter.From
+	}
+	if filter.To != "" {
+		dateRange["$lte"] = filter.To
+	}
+	if len(dateRange) > 0 {
+		query["date"] = dateRange
+	}
+	return query
+}
+
+// ListArticles returns the articles matching filter - GET METHOD.
+func (d *Database) ListArticles(filter ArticleFilter) (ArticlesArr, error) {
+	session, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+
+	order := []string{"_id"}
+	if filter.Newest {
+		order = []string{"-date", "-_id"}
+	}
+	query := db.Find(filterQuery(filter)).Sort(order...)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	result := ArticlesArr{}
+	if err := query.All(&result); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to retrive the articles, %v", err))
+	}
+	return result, nil
+}
+
+// ListTags counts the articles of every tag with an aggregation pipeline - GET METHOD.
+func (d *Database) ListTags(filter ArticleFilter) ([]TagCount, error) {
+	session, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+
+	result := []TagCount{}
+	pipeline := []bson.M{
+		{"$match": filterQuery(filter)},
+		{"$project": bson.M{"tags": bson.M{"$setUnion": []interface{}{"$tags", []string{}}}}},
+		{"$unwind": "$tags"},
+		{"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.D{{Name: "count", Value: -1}, {Name: "_id", Value: 1}}},
+	}
+	if err := db.Pipe(pipeline).All(&result); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to count the tags, %v", err))
+	}
+	return result, nil
+}
+
+// ReplaceTag swaps the tag on every article carrying it.
+// Every article is rewritten only if its tags did not change since they were read, so each
+// update is atomic; an article modified in between is read again and retried.
+func (d *Database) ReplaceTag(from, to string) (int, error) {
+	session, err := dial()
+	if err != nil {
+		return 0, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COLLECTION)
+
+	var articles ArticlesArr
+	if err := db.Find(bson.M{"tags": from}).Select(bson.M{"tags": 1}).All(&articles); err != nil {
+		return 0, errors.New(fmt.Sprintf("Error: Failed to retrive the articles for tag, %v", err))
+	}
+
+	updated := 0
+	for _, a := range articles {
+		for {
+			err := db.Update(bson.M{"_id": a.ID, "tags": a.Tags}, bson.M{
+				"$set": bson.M{"tags": replaceTag(a.Tags, from, to)},
+				// an update read before the tags changed fails its version check, see versionQuery.
+				"$inc": bson.M{"version": 1},
+			})
+			if err == nil {
+				updated += 1
+				break
+			}
+			if err != mgo.ErrNotFound {
+				return updated, errors.New(fmt.Sprintf("Error: updating the tags of article %d, %v", a.ID, err))
+			}
+			// tags changed concurrently, read them again.
+			if err := db.FindId(a.ID).Select(bson.M{"tags": 1}).One(&a); err != nil || !hasTag(a, from) {
+				break
+			}
+		}
+	}
+	log.Println("Replaced tag", from, "by", to, "on", updated, "articles")
+	return updated, nil
+}
+
+// ensureKeysIndex makes mongo drop idempotency records once they expired.
+func (d *Database) ensureKeysIndex(db *mgo.Collection) {
+	d.keysIndexOnce.Do(func() {
+		err := db.EnsureIndex(mgo.Index{Key: []string{"expires_at"}, ExpireAfter: time.Second, Name: "idempotency_expiry"})
+		if err != nil {
+			log.Println("Error: creating the idempotency expiry index, ", err)
+		}
+	})
+}
+
+// ReserveIdempotencyKey inserts record as pending, the unique _id makes concurrent reservations fail.
+func (d *Database) ReserveIdempotencyKey(record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	session, err := dial()
+	if err != nil {
+		return record, false, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(IDEMPOTENCY_COLLECTION)
+	d.ensureKeysIndex(db)
+
+	// mongo removes expired records only once a minute.
+	if _, err := db.RemoveAll(bson.M{"_id": record.Key, "expires_at": bson.M{"$lte": time.Now()}}); err != nil {
+		return record, false, errors.New(fmt.Sprintf("Error: Failed to expire the Idempotency-Key, %v", err))
+	}
+
+	err = db.Insert(record)
+	if err == nil {
+		return record, true, nil
+	}
+	if !mgo.IsDup(err) {
+		return record, false, errors.New(fmt.Sprintf("Error: Failed to reserve the Idempotency-Key, %v", err))
+	}
+
+	var existing IdempotencyRecord
+	if err := db.FindId(record.Key).One(&existing); err != nil {
+		return record, false, errors.New(fmt.Sprintf("Error: Failed to retrive the Idempotency-Key, %v", err))
+	}
+	return existing, false, nil
+}
+
+// CompleteIdempotencyKey stores the response of a reserved key.
+func (d *Database) CompleteIdempotencyKey(record IdempotencyRecord) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(IDEMPOTENCY_COLLECTION)
+	if err := db.UpdateId(record.Key, record); err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to store the Idempotency-Key response, %v", err))
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey drops a reserved key.
+func (d *Database) ReleaseIdempotencyKey(key string) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(IDEMPOTENCY_COLLECTION)
+	if err := db.RemoveId(key); err != nil && err != mgo.ErrNotFound {
+		return errors.New(fmt.Sprintf("Error: Failed to release the Idempotency-Key, %v", err))
+	}
+	return nil
+}
+
+// revisionID is the _id of a revision, unique per article and revision number.
+func revisionID(articleID, rev int) string {
+	return fmt.Sprintf("%d:%d", articleID, rev)
+}
+
+// AddRevision inserts the revision under the next number of the article's revision counter.
+func (d *Database) AddRevision(revision Revision) (Revision, error) {
+	session, err := dial()
+	if err != nil {
+		return revision, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(REVISION_COLLECTION)
+
+	counter := fmt.Sprintf("revisions:%d", revision.ArticleID)
+	if revision.Rev, err = nextSequence(session, counter, db, bson.M{"article_id": revision.ArticleID}, "rev"); err != nil {
+		return revision, err
+	}
+	doc := struct {
+		ID       string `bson:"_id"`
+		Revision `bson:",inline"`
+	}{revisionID(revision.ArticleID, revision.Rev), revision}
+	if err := db.Insert(doc); err != nil {
+		return revision, errors.New(fmt.Sprintf("Error: Failed to add the revision, %v", err))
+	}
+	return revision, nil
+}
+
+// ListRevisions returns the revisions of an article, oldest first.
+func (d *Database) ListRevisions(articleID int) ([]Revision, error) {
+	session, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(REVISION_COLLECTION)
+
+	result := []Revision{}
+	if err := db.Find(bson.M{"article_id": articleID}).Sort("rev").All(&result); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to retrive the revisions, %v", err))
+	}
+	return result, nil
+}
+
+// GetRevision returns revision 'rev' of an article.
+func (d *Database) GetRevision(articleID, rev int) (Revision, error) {
+	session, err := dial()
+	if err != nil {
+		return Revision{}, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(REVISION_COLLECTION)
+
+	var result Revision
+	if err := db.FindId(revisionID(articleID, rev)).One(&result); err != nil {
+		return result, errors.New(fmt.Sprintf("Error: Failed to retrive the revision, %v", err))
+	}
+	return result, nil
+}
+
+// AddAuditEntry inserts the entry under the next number of the audit counter.
+func (d *Database) AddAuditEntry(entry AuditEntry) (AuditEntry, error) {
+	session, err := dial()
+	if err != nil {
+		return entry, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(AUDIT_COLLECTION)
+
+	if entry.Seq, err = nextSequence(session, "audit", db, bson.M{}, "_id"); err != nil {
+		return entry, err
+	}
+	if err := db.Insert(entry); err != nil {
+		return entry, errors.New(fmt.Sprintf("Error: Failed to add the audit entry, %v", err))
+	}
+	return entry, nil
+}
+
+// ListAuditEntries returns the entries matching filter, oldest first.
+func (d *Database) ListAuditEntries(filter AuditFilter) ([]AuditEntry, error) {
+	session, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(AUDIT_COLLECTION)
+
+	query := bson.M{}
+	if filter.User != "" {
+		query["user"] = filter.User
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.ArticleID != 0 {
+		query["article_id"] = filter.ArticleID
+	}
+	timestamp := bson.M{}
+	if !filter.From.IsZero() {
+		timestamp["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timestamp["$lt"] = filter.To
+	}
+	if len(timestamp) > 0 {
+		query["timestamp"] = timestamp
+	}
+
+	result := []AuditEntry{}
+	if filter.Limit > 0 {
+		// the latest entries, turned back to oldest first.
+		err = db.Find(query).Sort("-_id").Limit(filter.Limit).All(&result)
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	} else {
+		err = db.Find(query).Sort("_id").All(&result)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to retrive the audit entries, %v", err))
+	}
+	return result, nil
+}
+
+// ensureUserIndex makes sure a user has at most one author profile.
+func (d *Database) ensureUserIndex(db *mgo.Collection) {
+	d.userIndexOnce.Do(func() {
+		err := db.EnsureIndex(mgo.Index{Key: []string{"user"}, Unique: true, Name: "author_user"})
+		if err != nil {
+			log.Println("Error: creating the author user index, ", err)
+		}
+	})
+}
+
+// AddAuthor inserts the author under the next number of the author counter.
+func (d *Database) AddAuthor(author Author) (int, error) {
+	session, err := dial()
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(AUTHOR_COLLECTION)
+	d.ensureUserIndex(db)
+
+	if author.ID, err = nextSequence(session, "authors", db, bson.M{}, "_id"); err != nil {
+		return -1, err
+	}
+	err = db.Insert(author)
+	if mgo.IsDup(err) {
+		// the id is new, so the user already has a profile.
+		return -1, errAuthorExists
+	}
+	if err != nil {
+		return -1, errors.New(fmt.Sprintf("Error: adding the author, %v", err))
+	}
+	log.Println("Added new Author with id :", author.ID)
+	return author.ID, nil
+}
+
+// GetAuthor retrives the author with 'id'.
+func (d *Database) GetAuthor(id int) (Author, error) {
+	session, err := dial()
+	if err != nil {
+		return Author{}, err
+	}
+	defer session.Close()
+
+	var result Author
+	if err := session.DB(DBNAME).C(AUTHOR_COLLECTION).FindId(id).One(&result); err != nil {
+		return result, errors.New(fmt.Sprintf("Error: Failed to retrive the author with ID, %v", err))
+	}
+	return result, nil
+}
+
+// GetAuthorByUser retrives the author profile of 'user'.
+func (d *Database) GetAuthorByUser(user string) (Author, error) {
+	session, err := dial()
+	if err != nil {
+		return Author{}, err
+	}
+	defer session.Close()
+
+	var result Author
+	if err := session.DB(DBNAME).C(AUTHOR_COLLECTION).Find(bson.M{"user": user}).One(&result); err != nil {
+		return result, errors.New(fmt.Sprintf("Error: Failed to retrive the author of the user, %v", err))
+	}
+	return result, nil
+}
+
+// ListAuthors returns every author ordered by id.
+func (d *Database) ListAuthors() ([]Author, error) {
+	session, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	result := []Author{}
+	if err := session.DB(DBNAME).C(AUTHOR_COLLECTION).Find(nil).Sort("_id").All(&result); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to retrive the authors, %v", err))
+	}
+	return result, nil
+}
+
+// UpdateAuthor replaces the profile fields of the author with author.ID.
+func (d *Database) UpdateAuthor(author Author) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(DBNAME).C(AUTHOR_COLLECTION).UpdateId(author.ID, bson.M{"$set": bson.M{
+		"name":       author.Name,
+		"bio":        author.Bio,
+		"contact":    author.Contact,
+		"avatar_url": author.AvatarURL,
+	}})
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to update the author with ID, %v", err))
+	}
+	return nil
+}
+
+// DeleteAuthor deletes the author with 'id'.
+func (d *Database) DeleteAuthor(id int) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if err := session.DB(DBNAME).C(AUTHOR_COLLECTION).RemoveId(id); err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to delete the author with ID, %v", err))
+	}
+	log.Println("Deleted the author with id: ", id)
+	return nil
+}
+
+// AddComment inserts the comment under the next number of the comment counter, so comments purged
+// with their article do not free ids.
+func (d *Database) AddComment(comment Comment) (Comment, error) {
+	session, err := dial()
+	if err != nil {
+		return comment, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(COMMENT_COLLECTION)
+
+	if comment.ID, err = nextSequence(session, "comments", db, bson.M{}, "_id"); err != nil {
+		return comment, err
+	}
+	if err := db.Insert(comment); err != nil {
+		return comment, errors.New(fmt.Sprintf("Error: adding the comment, %v", err))
+	}
+	return comment, nil
+}
+
+// GetComment retrives the comment with 'id'.
+func (d *Database) GetComment(id int) (Comment, error) {
+	session, err := dial()
+	if err != nil {
+		return Comment{}, err
+	}
+	defer session.Close()
+
+	var result Comment
+	if err := session.DB(DBNAME).C(COMMENT_COLLECTION).FindId(id).One(&result); err != nil {
+		return result, errors.New(fmt.Sprintf("Error: Failed to retrive the comment with ID, %v", err))
+	}
+	return result, nil
+}
+
+// ListComments returns every comment of an article ordered by id.
+func (d *Database) ListComments(articleID int) ([]Comment, error) {
+	session, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	result := []Comment{}
+	if err := session.DB(DBNAME).C(COMMENT_COLLECTION).Find(bson.M{"article_id": articleID}).Sort("_id").All(&result); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to retrive the comments, %v", err))
+	}
+	return result, nil
+}
+
+// UpdateComment replaces the changeable fields of the comment with comment.ID.
+func (d *Database) UpdateComment(comment Comment) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(DBNAME).C(COMMENT_COLLECTION).UpdateId(comment.ID, bson.M{"$set": bson.M{
+		"body":       comment.Body,
+		"status":     comment.Status,
+		"deleted":    comment.Deleted,
+		"updated_at": comment.UpdatedAt,
+	}})
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to update the comment with ID, %v", err))
+	}
+	return nil
+}
+
+// CountComments counts the approved comments of the articles with an aggregation pipeline.
+func (d *Database) CountComments(articleIDs ...int) (map[int]int, error) {
+	session, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var groups []struct {
+		ArticleID int `bson:"_id"`
+		Count     int `bson:"count"`
+	}
+	pipeline := []bson.M{
+		{"$match": bson.M{"article_id": bson.M{"$in": articleIDs}, "status": commentApproved, "deleted": bson.M{"$ne": true}}},
+		{"$group": bson.M{"_id": "$article_id", "count": bson.M{"$sum": 1}}},
+	}
+	if err := session.DB(DBNAME).C(COMMENT_COLLECTION).Pipe(pipeline).All(&groups); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to count the comments, %v", err))
+	}
+	counts := make(map[int]int)
+	for _, group := range groups {
+		counts[group.ArticleID] = group.Count
+	}
+	return counts, nil
+}
+
+// AddAttachment inserts the attachment under the next number of the attachment counter.
+func (d *Database) AddAttachment(attachment Attachment) (Attachment, error) {
+	session, err := dial()
+	if err != nil {
+		return attachment, err
+	}
+	defer session.Close()
+
+	db := session.DB(DBNAME).C(ATTACHMENT_COLLECTION)
+
+	if attachment.ID, err = nextSequence(session, "attachments", db, bson.M{}, "_id"); err != nil {
+		return attachment, err
+	}
+	if err := db.Insert(attachment); err != nil {
+		return attachment, errors.New(fmt.Sprintf("Error: adding the attachment, %v", err))
+	}
+	return attachment, nil
+}
+
+// GetAttachment retrives the attachment with 'id'.
+func (d *Database) GetAttachment(id int) (Attachment, error) {
+	session, err := dial()
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer session.Close()
+
+	var result Attachment
+	if err := session.DB(DBNAME).C(ATTACHMENT_COLLECTION).FindId(id).One(&result); err != nil {
+		return result, errors.New(fmt.Sprintf("Error: Failed to retrive the attachment with ID, %v", err))
+	}
+	return result, nil
+}
+
+// ListAttachments returns the attachments of an article ordered by id.
+func (d *Database) ListAttachments(articleID int) ([]Attachment, error) {
+	session, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	result := []Attachment{}
+	if err := session.DB(DBNAME).C(ATTACHMENT_COLLECTION).Find(bson.M{"article_id": articleID}).Sort("_id").All(&result); err != nil {
+		return nil, errors.New(fmt.Sprintf("Error: Failed to retrive the attachments, %v", err))
+	}
+	return result, nil
+}
+
+// DeleteAttachment deletes the attachment with 'id'.
+func (d *Database) DeleteAttachment(id int) error {
+	session, err := dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if err := session.DB(DBNAME).C(ATTACHMENT_COLLECTION).RemoveId(id); err != nil {
+		return errors.New(fmt.Sprintf("Error: Failed to delete the attachment with ID, %v", err))
+	}
+	return nil
 }
@@ -0,0 +1,133 @@
+// Content fingerprints used to detect duplicate and near-duplicate articles.
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// texts with fewer keywords only get exact duplicate detection, their simhash is too noisy.
+const minSimHashTokens = 10
+
+// simHashBands is the number of bands a simhash is split into to look up near-duplicates: two
+// simhashes differing in fewer bits than there are bands have at least one band in common.
+const simHashBands = 8
+
+// duplicateThreshold is the largest number of differing simhash bits for two articles to be
+// near-duplicates, set with the DUPLICATE_THRESHOLD env variable; a negative value disables it.
+// It stays below simHashBands, the band lookup would miss near-duplicates further apart.
+var duplicateThreshold = bandThreshold(envInt("DUPLICATE_THRESHOLD", 6))
+
+// bandThreshold clamps threshold to the largest distance the simhash bands find every near-duplicate within.
+func bandThreshold(threshold int) int {
+	if threshold >= simHashBands {
+		log.Println("Error: DUPLICATE_THRESHOLD above", simHashBands-1, "is not supported, using", simHashBands-1)
+		return simHashBands - 1
+	}
+	return threshold
+}
+
+// envInt reads an integer env variable, returning def when it is not set or invalid.
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Println("Error: invalid", name, "env variable, using", def)
+		return def
+	}
+	return i
+}
+
+// DuplicateError is returned when an article with the same or nearly the same content exists.
+type DuplicateError struct {
+	ID int
+	// Near is set when the content is not the same but within the simhash threshold.
+	Near bool
+	// Distance is the number of differing simhash bits of a near-duplicate.
+	Distance int
+}
+
+func (e *DuplicateError) Error() string {
+	if e.Near {
+		return fmt.Sprintf("Info: Similar article already exists in database, %d", e.ID)
+	}
+	return fmt.Sprintf("Info: Article already exists in database, %d", e.ID)
+}
+
+// fingerprint sets the content hash and simhash of the article from its title and body.
+func fingerprint(a *Article) {
+	tokens := keywords(append(tokenize(titleText(*a)), tokenize(a.Body)...))
+
+	// case, punctuation and spacing do not make an article different.
+	sum := sha256.Sum256([]byte(strings.Join(tokenize(titleText(*a)), " ") + "\n" + strings.Join(tokenize(a.Body), " ")))
+	a.ContentHash = hex.EncodeToString(sum[:])
+
+	a.SimHash = 0
+	if len(tokens) >= minSimHashTokens {
+		a.SimHash = int64(simHash(tokens))
+	}
+	a.SimHashBands = bands(a.SimHash)
+}
+
+// bands returns the keys of the bands of a simhash, nil for none; the band number is in the high
+// bits so the same bits in different bands do not match.
+func bands(simHash int64) []int {
+	if simHash == 0 {
+		return nil
+	}
+	width := uint(64 / simHashBands)
+	keys := make([]int, simHashBands)
+	for i := range keys {
+		keys[i] = i<<width | int(uint64(simHash)>>(width*uint(i))&(1<<width-1))
+	}
+	return keys
+}
+
+// simHash computes the 64 bit simhash of tokens, every occurrence of a word weighs the same.
+func simHash(tokens []string) uint64 {
+	var weights [64]int
+	for _, token := range tokens {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		sum := h.Sum64()
+		for bit := uint(0); bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit] += 1
+			} else {
+				weights[bit] -= 1
+			}
+		}
+	}
+
+	var result uint64
+	for bit := uint(0); bit < 64; bit++ {
+		if weights[bit] > 0 {
+			result |= 1 << bit
+		}
+	}
+	return result
+}
+
+// hammingDistance counts the differing bits of two simhashes.
+func hammingDistance(a, b int64) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+// isNearDuplicate reports whether the simhashes are within the configured threshold.
+func isNearDuplicate(a, b int64) (int, bool) {
+	if a == 0 || b == 0 || duplicateThreshold < 0 {
+		return 0, false
+	}
+	distance := hammingDistance(a, b)
+	return distance, distance <= duplicateThreshold
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const duplicateBody = "Some text, potentially containing simple markup about how potato chips are great and why sugar is worse for you."
+
+func TestHandler_ArticlesHandlerDuplicateContent(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "Potato chips", Date: "2016-09-22", Body: duplicateBody, Tags: []string{"health"}})
+
+	// same content with other case, spacing, date and tags.
+	rr := serve(h, "POST", "http://localhost:8984/articles",
+		`{"title":"POTATO  chips!","date":"2018-01-01","body":"`+duplicateBody+`","tags":["food"]}`)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var conflict Conflict
+	json.Unmarshal(rr.Body.Bytes(), &conflict)
+	assert.Equal(t, Conflict{Error: "Info: Article already exists in database, 1", ID: 1}, conflict)
+}
+
+func TestHandler_ArticlesHandlerNearDuplicate(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "Potato chips", Date: "2016-09-22", Body: duplicateBody, Tags: []string{"health"}})
+
+	rr := serve(h, "POST", "http://localhost:8984/articles",
+		`{"title":"Potato chips","date":"2016-09-22","body":"`+duplicateBody+` Really.","tags":["health"]}`)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var conflict Conflict
+	json.Unmarshal(rr.Body.Bytes(), &conflict)
+	assert.Equal(t, Conflict{Error: "Info: Similar article already exists in database, 1", ID: 1, Near: true}, conflict)
+
+	rr = serve(h, "POST", "http://localhost:8984/articles",
+		`{"title":"Elections","date":"2016-09-22","body":"Votes were counted all night long in every single town of the country.","tags":["health"]}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func TestHandler_ArticlesHandlerOnDuplicate(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "Potato chips", Date: "2016-09-22", Body: duplicateBody, Tags: []string{"health"}})
+	data := `{"title":"Potato chips","date":"2018-01-01","body":"` + duplicateBody + `","tags":["food"]}`
+
+	rr := serve(h, "POST", "http://localhost:8984/articles", data)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, "http://localhost:8984/articles/1", rr.Header().Get("Location"))
+
+	rr = serve(h, "POST", "http://localhost:8984/articles?on_duplicate=return", data)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "http://localhost:8984/articles/1", rr.Header().Get("Location"))
+	assert.Contains(t, rr.Body.String(), `"date":"2016-09-22"`)
+
+	rr = serve(h, "POST", "http://localhost:8984/articles?on_duplicate=upsert", data)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	article, _ := h.database.GetArticleByID(1)
+	assert.Equal(t, "2018-01-01", article.Date)
+	assert.Equal(t, []string{"food"}, article.Tags)
+
+	rr = serve(h, "POST", "http://localhost:8984/articles?on_duplicate=ignore", data)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func TestHammingDistance(t *testing.T) {
+	assert.Equal(t, 0, hammingDistance(5, 5))
+	assert.Equal(t, 2, hammingDistance(0, 3))
+	assert.Equal(t, 64, hammingDistance(0, -1))
+}
+
+func TestBandThreshold(t *testing.T) {
+	assert.Equal(t, 6, bandThreshold(6))
+	assert.Equal(t, -1, bandThreshold(-1))
+	assert.Equal(t, simHashBands-1, bandThreshold(simHashBands))
+	assert.Equal(t, simHashBands-1, bandThreshold(40))
+}
+
+func TestBands(t *testing.T) {
+	assert.Nil(t, bands(0))
+	assert.Equal(t, []int{0<<8 | 0xff, 1<<8 | 0x01, 2 << 8, 3 << 8, 4 << 8, 5 << 8, 6 << 8, 7<<8 | 0x7f}, bands(0x7f000000000001ff))
+
+	// simhashes within fewer bits than bands share one, so the index finds every near-duplicate.
+	simHash := int64(0x0123456789abcdef)
+	for flip := uint(0); flip+simHashBands-1 <= 64; flip += 3 {
+		near := simHash
+		for bit := flip; bit < flip+simHashBands-1; bit++ {
+			near ^= 1 << (bit % 64)
+		}
+		shared := 0
+		for i, key := range bands(near) {
+			if key == bands(simHash)[i] {
+				shared += 1
+			}
+		}
+		assert.True(t, shared > 0)
+	}
+}
@@ -0,0 +1,169 @@
+// RSS and Atom feeds of the published articles.
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/feeds"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// feedSize is the number of articles in a feed, feedMaxAge how long clients may cache one.
+	feedSize   = envInt("FEED_SIZE", 20)
+	feedMaxAge = envDuration("FEED_MAX_AGE", 5*time.Minute)
+)
+
+// feedTypes maps the feed formats to their content type.
+var feedTypes = map[string]string{
+	"rss":  "application/rss+xml; charset=utf-8",
+	"atom": "application/atom+xml; charset=utf-8",
+}
+
+// publishedAt returns when the article was published, its date for articles published before it was recorded.
+func publishedAt(a Article) time.Time {
+	if a.PublishedAt != nil {
+		return *a.PublishedAt
+	}
+	date, _ := time.Parse(dateLayout, a.Date)
+	return date
+}
+
+// articlePath returns the path of the article by its slug, by its id for articles without one.
+func articlePath(a Article) string {
+	if a.Slug != "" {
+		return "/articles/by-slug/" + a.Slug
+	}
+	return "/articles/" + strconv.Itoa(a.ID)
+}
+
+// articleLink returns the url of the article by its slug, by its id for articles without one.
+func articleLink(r *http.Request, a Article) string {
+	return absoluteURL(r, articlePath(a))
+}
+
+// feedArticles returns the newest published articles matching filter.
+func (h *Handler) feedArticles(filter ArticleFilter) (ArticlesArr, error) {
+	filter.Published, filter.Newest, filter.Limit = true, true, feedSize
+	return h.database.ListArticles(filter)
+}
+
+// buildFeed makes the feed of the articles, newest first.
+func (h *Handler) buildFeed(r *http.Request, title, link string, articles ArticlesArr) *feeds.Feed {
+	feed := &feeds.Feed{
+		Title:       title,
+		Link:        &feeds.Link{Href: link},
+		Description: title,
+	}
+
+	authors := make(map[int]string)
+	for _, a := range articles {
+		published := publishedAt(a)
+		item := &feeds.Item{
+			Id:          articleURL(r, a.ID),
+			Title:       titleText(a),
+			Link:        &feeds.Link{Href: articleLink(r, a)},
+			Description: a.Excerpt,
+			Created:     published,
+			Updated:     published,
+		}
+		if content, _, err := renderBody(a); err == nil {
+			item.Content = content
+		} else {
+			log.Println("Error: rendering article", a.ID, err)
+		}
+
+		if a.AuthorID != 0 {
+			if _, ok := authors[a.AuthorID]; !ok {
+				author, err := h.database.GetAuthor(a.AuthorID)
+				if err != nil {
+					log.Println(err.Error())
+				}
+				authors[a.AuthorID] = author.Name
+			}
+			if authors[a.AuthorID] != "" {
+				item.Author = &feeds.Author{Name: authors[a.AuthorID]}
+			}
+		}
+
+		if published.After(feed.Updated) {
+			feed.Updated = published
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed
+}
+
+// writeFeed answers the feed in 'format' with caching headers; the ETag is the hash of the feed so
+// that a changed article changes it too, conditional requests are answered by http.ServeContent.
+func writeFeed(w http.ResponseWriter, r *http.Request, feed *feeds.Feed, format string) {
+	var content string
+	var err error
+	if format == "atom" {
+		content, err = feed.ToAtom()
+	} else {
+		content, err = feed.ToRss()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	w.Header().Set("Content-Type", feedTypes[format])
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(feedMaxAge.Seconds())))
+	if !feed.Updated.IsZero() {
+		w.Header().Set("Last-Modified", feed.Updated.UTC().Format(http.TimeFormat))
+	}
+	// no modification time as edits of published articles do not change it, only the ETag is compared.
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader([]byte(content)))
+}
+
+// GetArticlesFeed answers the newest published articles as RSS or Atom - GET METHOD.
+func (h *Handler) GetArticlesFeed(w http.ResponseWriter, r *http.Request) {
+	format := mux.Vars(r)["format"]
+
+	articles, err := h.feedArticles(ArticleFilter{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	writeFeed(w, r, h.buildFeed(r, "Articles", absoluteURL(r, "/articles"), articles), format)
+}
+
+// GetTagFeed answers the newest published articles of a tag, 'format' selects rss (default) or atom - GET METHOD.
+func (h *Handler) GetTagFeed(w http.ResponseWriter, r *http.Request) {
+	tagName := mux.Vars(r)["tagName"]
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "rss"
+	}
+	if _, ok := feedTypes[format]; !ok {
+		http.Error(w, "Error: Invalid format, use rss or atom.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	articles, err := h.feedArticles(ArticleFilter{Tag: tagName})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	if len(articles) == 0 {
+		http.Error(w, "Error: Tag not found.", http.StatusNotFound)
+		return
+	}
+	link := absoluteURL(r, "/tags/"+url.PathEscape(tagName))
+	writeFeed(w, r, h.buildFeed(r, "Articles tagged "+tagName, link, articles), format)
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Feeds(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "Older", Date: "2018-10-04", Body: "old news", Tags: []string{"aaa"}},
+		Article{Title: "Newer", Date: "2018-10-05", Body: "new *news*", Format: formatMarkdown, Tags: []string{"bbb", "c c"}},
+		Article{Title: "Draft", Date: "2018-10-06", Body: "not yet", Tags: []string{"aaa"}, Status: statusDraft},
+	)
+
+	// feeds are answered without credentials.
+	req, _ := http.NewRequest("GET", "http://localhost:8984/feeds/articles.rss", nil)
+	rr := httptest.NewRecorder()
+	newRouter(h).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/rss+xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+	body := rr.Body.String()
+	assert.True(t, strings.Index(body, "<title>Newer</title>") < strings.Index(body, "<title>Older</title>"))
+	assert.Contains(t, body, "<em>news</em>")
+	assert.Contains(t, body, "http://localhost:8984/articles/by-slug/newer")
+	assert.NotContains(t, body, "Draft")
+
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	rr = httptest.NewRecorder()
+	newRouter(h).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+
+	rr = serve(h, "GET", "http://localhost:8984/feeds/articles.atom", "")
+	assert.Equal(t, "application/atom+xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+
+	rr = serve(h, "GET", "http://localhost:8984/feeds/tag/aaa?format=atom", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Older")
+	assert.NotContains(t, rr.Body.String(), "Newer")
+
+	// only the newest published articles make the feed.
+	feedSize = 1
+	defer func() { feedSize = 20 }()
+	rr = serve(h, "GET", "http://localhost:8984/feeds/articles.rss", "")
+	assert.Contains(t, rr.Body.String(), "<title>Newer</title>")
+	assert.NotContains(t, rr.Body.String(), "<title>Older</title>")
+
+	// the tag is escaped once in the link of its feed.
+	rr = serve(h, "GET", "http://localhost:8984/feeds/tag/c%20c", "")
+	assert.Contains(t, rr.Body.String(), "<link>http://localhost:8984/tags/c%20c</link>")
+
+	rr = serve(h, "GET", "http://localhost:8984/feeds/tag/zzz", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = serve(h, "GET", "http://localhost:8984/feeds/tag/aaa?format=json", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
@@ -7,13 +7,19 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 
+	"awesomeProject/errors"
 	"github.com/gorilla/mux"
 )
 
@@ -22,8 +28,18 @@ const (
 	password = "password"
 )
 
+// errInvalidDate is returned for dates not given as eg: 20160112.
+var errInvalidDate = errors.New("Error: Invalid Date Entered.")
+
 type Handler struct {
-	database Database
+	database Store
+	blobs    BlobStore
+
+	// scheduled wakes the publish scheduler when a publication was scheduled, nil when it does not run.
+	scheduled chan struct{}
+
+	// sitemap is kept up to date by audit, see sitemap.go.
+	sitemap sitemap
 }
 
 func prettyprint(b []byte) ([]byte, error) {
@@ -33,15 +49,123 @@ func prettyprint(b []byte) ([]byte, error) {
 }
 
 func writeJson(w http.ResponseWriter, data interface{}) {
-	bJson, err := json.Marshal(data)
+	writeJsonStatus(w, http.StatusOK, data)
+}
+
+// writeJsonStatus answers data as compact json, or in the format negotiated by Negotiate.
+func writeJsonStatus(w http.ResponseWriter, status int, data interface{}) {
+	writeNegotiated(w, status, data)
+}
+
+// baseURL, when set by BASE_URL eg: https://api.example.com, is the url the absolute urls are made on.
+var baseURL = loadBaseURL(os.Getenv("BASE_URL"))
+
+// trustedProxies are the addresses, eg: 10.0.0.0/8,192.0.2.7, whose X-Forwarded-Proto and
+// X-Forwarded-Host headers are honoured, set by TRUSTED_PROXIES.
+var trustedProxies = loadProxies(os.Getenv("TRUSTED_PROXIES"))
+
+// loadBaseURL parses the BASE_URL, nil when it is not set or invalid.
+func loadBaseURL(base string) *url.URL {
+	if base == "" {
+		return nil
+	}
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Println("Error: invalid BASE_URL", base)
+		return nil
+	}
+	u.Path, u.RawPath = strings.TrimSuffix(u.Path, "/"), strings.TrimSuffix(u.RawPath, "/")
+	return u
+}
+
+// loadProxies parses the TRUSTED_PROXIES, a list of addresses and networks separated by commas.
+func loadProxies(proxies string) []*net.IPNet {
+	result := []*net.IPNet{}
+	for _, entry := range strings.Split(proxies, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Println("Error: invalid TRUSTED_PROXIES entry", entry)
+			continue
+		}
+		result = append(result, network)
+	}
+	return result
+}
+
+// fromTrustedProxy reports whether the request was sent by one of the trusted proxies.
+func fromTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		panic(err)
+		host = r.RemoteAddr
 	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
 
-	prettyB, _ := prettyprint(bJson)
-	w.Header().Add("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	w.Write(prettyB)
+// absoluteURL returns the url of path, escaped already, on the BASE_URL or else on the host the
+// request was sent to. The X-Forwarded-Proto and X-Forwarded-Host headers are only honoured from
+// trusted proxies, as the urls end up in responses shared caches keep.
+func absoluteURL(r *http.Request, path string) string {
+	u := &url.URL{Scheme: "http", Host: r.Host}
+	if baseURL != nil {
+		u.Scheme, u.Host, u.Path = baseURL.Scheme, baseURL.Host, baseURL.EscapedPath()
+	} else {
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+		if fromTrustedProxy(r) {
+			if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+				u.Scheme = proto
+			}
+			if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+				u.Host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+			}
+		}
+	}
+
+	u.RawPath = u.Path + path
+	u.Path = u.RawPath
+	if unescaped, err := url.PathUnescape(u.RawPath); err == nil {
+		u.Path = unescaped
+	}
+	return u.String()
+}
+
+// articleURL returns the absolute url of the article.
+func articleURL(r *http.Request, id int) string {
+	return absoluteURL(r, "/articles/"+strconv.Itoa(id))
+}
+
+// articleETag returns the strong entity tag of the article's JSON representation.
+func articleETag(article Article) string {
+	// comments are a resource of their own and the sanitize report is only answered to the writer,
+	// neither changes the article.
+	article.CommentCount, article.Sanitized = 0, nil
+	bJson, err := json.Marshal(article)
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(bJson)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
 }
 
 type authHandler func(w http.ResponseWriter, r *http.Request)
@@ -70,14 +194,77 @@ func Authentication(pass authHandler) authHandler {
 }
 
 func validate(user, pass string) bool {
-	if username == user && password == pass {
+	if acc, ok := accounts[user]; ok && acc.password == pass {
 		return true
 	}
 	return false
 }
 
+// account of a user of the API.
+type account struct {
+	password string
+	roles    []string
+}
+
+// accounts holds the users allowed in, the test user has every role. More users are added with the
+// USERS env variable as comma separated name:password:role|role entries eg: bob:secret:editor.
+var accounts = loadAccounts(os.Getenv("USERS"))
+
+func loadAccounts(users string) map[string]account {
+	result := map[string]account{
+		username: {password: password, roles: []string{"admin", "editor"}},
+	}
+	for _, entry := range strings.Split(users, ",") {
+		fields := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(fields) < 2 || fields[0] == "" {
+			if entry != "" {
+				log.Println("Error: invalid USERS entry", entry)
+			}
+			continue
+		}
+		acc := account{password: fields[1]}
+		if len(fields) == 3 && fields[2] != "" {
+			acc.roles = strings.Split(fields[2], "|")
+		}
+		result[fields[0]] = acc
+	}
+	return result
+}
+
+// hasRole reports whether the user the request was authenticated with has role.
+func hasRole(r *http.Request, role string) bool {
+	for _, granted := range accounts[currentUser(r)].roles {
+		if granted == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole only lets users with role through, others are answered 403 Forbidden.
+func RequireRole(role string, pass authHandler) authHandler {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !hasRole(r, role) {
+			http.Error(w, "Error: "+role+" role required", http.StatusForbidden)
+			return
+		}
+		pass(w, r)
+	}
+}
+
 // ArticlesHandler creates a new record - POST METHOD.
+// 'on_duplicate' decides what happens when the article already exists: error (default) answers
+// 409 Conflict, return answers the existing article and upsert overwrites it with the posted one.
 func (h *Handler) ArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	onDuplicate := r.URL.Query().Get("on_duplicate")
+	if onDuplicate == "" {
+		onDuplicate = "error"
+	}
+	if onDuplicate != "error" && onDuplicate != "return" && onDuplicate != "upsert" {
+		http.Error(w, "Error: Invalid on_duplicate, use return, error or upsert.", http.StatusUnprocessableEntity)
+		return
+	}
+
 	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
 	log.Println(string(body))
 	if err != nil {
@@ -87,28 +274,107 @@ func (h *Handler) ArticlesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var articleStruct Article
-	if err := json.Unmarshal(body, &articleStruct); err != nil {
+	if err := decodeBody(r, body, &articleStruct); err != nil {
 		log.Println("Error: ArticlesHandler - Unmarshalling data, ", err)
 		http.Error(w, "Error: ArticlesHandler - Unmarshalling data"+" : "+err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 	log.Println(articleStruct)
+	if !validFormat(articleStruct.Format) {
+		http.Error(w, "Error: Invalid format, use plain, markdown or html.", http.StatusUnprocessableEntity)
+		return
+	}
+	sanitized := sanitizeArticle(&articleStruct)
+
+	// new articles are drafts until published, see workflow.go.
+	articleStruct.Status, articleStruct.PublishedAt = statusDraft, nil
+
+	author, err := h.authorOf(currentUser(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	articleStruct.AuthorID = author.ID
 
 	// write into database
 	id, err := h.database.AddArticle(articleStruct)
+	if dupErr, isDuplicate := err.(*DuplicateError); isDuplicate {
+		log.Println(err.Error())
+		h.duplicateArticle(w, r, dupErr, articleStruct, onDuplicate)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Println(err.Error())
 		return
 	}
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.Header().Add("Location", "articles/"+strconv.Itoa(id))
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte("Added the article successfully..."))
+	// answer with the article as stored, including its id.
+	created, err := h.database.GetArticleByID(id)
+	if err != nil {
+		log.Println(err.Error())
+		created = articleStruct
+		created.ID = id
+	}
+	h.recordRevision(r, "create", Article{}, created)
+	h.audit(r, "create", Article{}, created)
+	created.Sanitized = sanitized
+
+	w.Header().Set("Location", articleURL(r, id))
+	w.Header().Set("ETag", representationETag(w, articleETag(created)))
+	writeJsonStatus(w, http.StatusCreated, created)
 	return
 }
 
+// duplicateArticle answers a POST of an article that already exists according to the on_duplicate mode.
+// The existing article is only returned to those who can read it and overwritten by those who can edit it.
+func (h *Handler) duplicateArticle(w http.ResponseWriter, r *http.Request, dupErr *DuplicateError, data Article, onDuplicate string) {
+	w.Header().Set("Location", articleURL(r, dupErr.ID))
+
+	existing, err := h.database.GetArticleByID(dupErr.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	if !canRead(r, existing) {
+		onDuplicate = "error"
+	}
+	if onDuplicate == "upsert" && !h.canEdit(r, existing) {
+		http.Error(w, "Error: Only the author of the article or an editor can change it.", http.StatusForbidden)
+		return
+	}
+
+	switch onDuplicate {
+	case "return":
+		w.Header().Set("ETag", representationETag(w, articleETag(existing)))
+		writeJson(w, h.withCommentCount(existing))
+
+	case "upsert":
+		data.ID, data.Version = existing.ID, existing.Version
+		if err := h.database.UpdateArticle(data); err != nil {
+			http.Error(w, err.Error(), storeErrorStatus(err))
+			log.Println(err.Error())
+			return
+		}
+		updated, err := h.database.GetArticleByID(dupErr.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Println(err.Error())
+			return
+		}
+		h.recordRevision(r, "update", existing, updated)
+		h.audit(r, "update", existing, updated)
+		w.Header().Set("ETag", representationETag(w, articleETag(updated)))
+		writeJson(w, h.withCommentCount(updated))
+
+	default:
+		writeJsonStatus(w, http.StatusConflict, Conflict{Error: dupErr.Error(), ID: dupErr.ID, Near: dupErr.Near})
+	}
+}
+
 // GetArticleByID retrives record by 'id' - GET METHOD.
+// With 'render=html' the body is answered as sanitized HTML too, with a table of contents.
 func (h *Handler) GetArticleByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	log.Println(vars)
@@ -124,15 +390,250 @@ func (h *Handler) GetArticleByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	article, err := h.database.GetArticleByID(articleID)
+	if err == nil && !canRead(r, article) {
+		err = errors.New("Error: Failed to retrive the article with ID, not found")
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		log.Println(err.Error())
 		return
 	}
 	log.Println(article)
+	h.writeArticle(w, r, article)
+}
+
+// writeArticle answers a GET of the article with its comment count, rendered with 'render=html'.
+func (h *Handler) writeArticle(w http.ResponseWriter, r *http.Request, article Article) {
+	render := r.URL.Query().Get("render")
+	if render != "" && render != "html" {
+		http.Error(w, "Error: Invalid render, use html.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	article = h.withCommentCount(article)
+	etag := articleETag(article)
+	if render != "" {
+		// the rendered representation differs from the plain one, so does its ETag.
+		etag = strings.TrimSuffix(etag, `"`) + `-html"`
+	}
+	etag = representationETag(w, etag)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag, false) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 
+	if render != "" {
+		rendered := RenderedArticle{Article: article}
+		var err error
+		if rendered.HTML, rendered.TOC, err = renderBody(article); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Println(err.Error())
+			return
+		}
+		writeJson(w, rendered)
+		return
+	}
 	writeJson(w, article)
-	return
+}
+
+// UpdateArticle replaces title, date, body and tags of record 'id' - PUT METHOD.
+// With an If-Match header the update only happens while the article still has that ETag.
+func (h *Handler) UpdateArticle(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Println("Error: getting the product ID, ", err)
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		log.Println("Error: in updating article - ", err)
+		http.Error(w, "Error: in updating article.", http.StatusUnprocessableEntity)
+		return
+	}
+	var articleStruct Article
+	if err := decodeBody(r, body, &articleStruct); err != nil {
+		log.Println("Error: UpdateArticle - Unmarshalling data, ", err)
+		http.Error(w, "Error: UpdateArticle - Unmarshalling data"+" : "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if !validFormat(articleStruct.Format) {
+		http.Error(w, "Error: Invalid format, use plain, markdown or html.", http.StatusUnprocessableEntity)
+		return
+	}
+	sanitized := sanitizeArticle(&articleStruct)
+
+	current, ok := h.checkIfMatch(w, r, articleID)
+	if !ok {
+		return
+	}
+
+	// the version read with the If-Match check makes the update fail if the article changed since.
+	articleStruct.ID, articleStruct.Version = current.ID, current.Version
+	if err := h.database.UpdateArticle(articleStruct); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+
+	updated, err := h.database.GetArticleByID(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	h.recordRevision(r, "update", current, updated)
+	h.audit(r, "update", current, updated)
+	updated.Sanitized = sanitized
+
+	w.Header().Set("ETag", representationETag(w, articleETag(updated)))
+	writeJson(w, h.withCommentCount(updated))
+}
+
+// DeleteArticleByID deletes record 'id' - DELETE METHOD.
+// With an If-Match header the article is only deleted while it still has that ETag.
+func (h *Handler) DeleteArticleByID(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Println("Error: getting the product ID, ", err)
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	current, ok := h.checkIfMatch(w, r, articleID)
+	if !ok {
+		return
+	}
+
+	if err := h.database.DeleteArticleByID(current.ID, current.Version); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	h.audit(r, "delete", current, Article{})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkIfMatch loads article 'id' for a change and verifies the If-Match header of the request against
+// its ETag, answering 404, 403 Forbidden or 412 Precondition Failed itself when the request can not go on.
+// Articles the caller can not read are not found, those the caller can not edit forbidden, see workflow.go.
+func (h *Handler) checkIfMatch(w http.ResponseWriter, r *http.Request, id int) (Article, bool) {
+	article, err := h.database.GetArticleByID(id)
+	if err == nil && !canRead(r, article) {
+		err = errors.New("Error: Failed to retrive the article with ID, not found")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		log.Println(err.Error())
+		return Article{}, false
+	}
+	if !h.canEdit(r, article) {
+		http.Error(w, "Error: Only the author of the article or an editor can change it.", http.StatusForbidden)
+		return Article{}, false
+	}
+
+	// every representation of the article, eg: xml or rendered, has the version of its json one.
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch != "" && !etagMatches(etagVersions(ifMatch), articleETag(article), true) {
+		w.Header().Set("ETag", representationETag(w, articleETag(article)))
+		http.Error(w, "Error: Article was modified, If-Match does not match its ETag.", http.StatusPreconditionFailed)
+		return article, false
+	}
+	return article, true
+}
+
+// etagVersions drops the representation suffixes, eg: -xml, from the entity tags of the header.
+func etagVersions(header string) string {
+	tags := strings.Split(header, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if dash := strings.Index(tag, "-"); dash > 0 && strings.HasSuffix(tag, `"`) {
+			tag = tag[:dash] + `"`
+		}
+		tags[i] = tag
+	}
+	return strings.Join(tags, ", ")
+}
+
+// etagMatches reports whether the If-Match or If-None-Match header value lists etag or is "*".
+// If-Match uses the strong comparison where weak tags never match.
+func etagMatches(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// storeErrorStatus maps an error of the store to the response status.
+func storeErrorStatus(err error) int {
+	if _, isDuplicate := err.(*DuplicateError); isDuplicate {
+		return http.StatusConflict
+	}
+	if err == errVersionConflict {
+		return http.StatusPreconditionFailed
+	}
+	if err == errAuthorExists {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// formatDate converts the date given in url (eg: 20160112) to the format stored in database (2016-01-12).
+func formatDate(dateInfo string) (string, error) {
+	// assumption are made for the date as below
+	// the complete date len is 8 ie 20160112 which is straight forward to split.
+	// if provided as 2016112, then assumptions can be 20160112 or 20161102 or 20161120
+	// for now lets request the user to provide valid date with eg:20160112
+	if len(dateInfo) != 8 {
+		return "", errInvalidDate
+	}
+	if _, err := strconv.Atoi(dateInfo); err != nil {
+		return "", errInvalidDate
+	}
+	dateRune := []rune(dateInfo)
+	return string(dateRune[:4]) + "-" + string(dateRune[4:6]) + "-" + string(dateRune[6:]), nil
+}
+
+// dateRange reads the optional 'from' and 'to' url params given as eg: 20160112.
+func dateRange(params url.Values) (string, string, error) {
+	var from, to string
+	var err error
+	if param := params.Get("from"); param != "" {
+		if from, err = formatDate(param); err != nil {
+			return "", "", err
+		}
+	}
+	if param := params.Get("to"); param != "" {
+		if to, err = formatDate(param); err != nil {
+			return "", "", err
+		}
+	}
+	return from, to, nil
+}
+
+// intParam parses an optional integer url param, returning def when it is not given.
+func intParam(param string, def int) (int, error) {
+	if param == "" {
+		return def, nil
+	}
+	return strconv.Atoi(param)
 }
 
 func unique(strSlice []string) []string {
@@ -158,19 +659,20 @@ func (h *Handler) GetArticleByTagNameDate(w http.ResponseWriter, r *http.Request
 	dateInfo := vars["date"]
 	log.Println(dateInfo)
 
-	// assumption are made for the date as below
-	// the complete date len is 8 ie 20160112 which is straight forward to split.
-	// if provided as 2016112, then assumptions can be 20160112 or 20161102 or 20161120
-	// for now lets request the user to provide valid date with eg:20160112
-	if len(dateInfo) != 8 {
-		http.Error(w, "Error: Invalid Date Entered.", http.StatusUnprocessableEntity)
+	date, err := formatDate(dateInfo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
-	dateRune := []rune(dateInfo)
-	date := string(dateRune[:4]) + "-" + string(dateRune[4:6]) + "-" + string(dateRune[6:])
 	log.Println(date)
 
 	articles, err := h.database.GetArticleByTagDate(tagName, date)
+	if err == nil {
+		articles = readable(r, articles)
+		if len(articles) == 0 {
+			err = errors.New("Error: Failed to retrive the articles for date&Tag, <nil>")
+		}
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		log.Println(err.Error())
@@ -212,7 +714,7 @@ func (h *Handler) DeleteArticle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var articleStruct Article
-	if err := json.Unmarshal(body, &articleStruct); err != nil {
+	if err := decodeBody(r, body, &articleStruct); err != nil {
 		log.Println("Error: ArticlesHandler - Unmarshalling data, ", err)
 		http.Error(w, "Error: ArticlesHandler - Unmarshalling data", http.StatusUnprocessableEntity)
 		return
@@ -220,7 +722,7 @@ func (h *Handler) DeleteArticle(w http.ResponseWriter, r *http.Request) {
 	log.Println(articleStruct)
 
 	// delete from database
-	_, err = h.database.DeleteArticle(articleStruct)
+	deleted, err := h.database.DeleteArticle(articleStruct)
 	if err != nil {
 		log.Println("Error: DeleteHandler -", err.Error())
 		if strings.Contains(err.Error(), "not found") {
@@ -230,6 +732,7 @@ func (h *Handler) DeleteArticle(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	h.audit(r, "delete", deleted, Article{})
 
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
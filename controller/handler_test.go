@@ -2,7 +2,9 @@ package controller
 
 import (
 	"bytes"
+	"encoding/json"
 	"strconv"
+	"strings"
 	"testing"
 
 	"net/http"
@@ -13,7 +15,7 @@ import (
 
 func TestHandler_GetArticleByIDValidData(t *testing.T) {
 	//data := []byte(`{"id":1,"title":"Global Warming","date":"2018-10-04","body":"Change in climate and vegetation","tags":["world","climate","nature"]}`)
-	req, err := http.NewRequest("GET", "http://localhost:8984/articles/3", nil)
+	req, err := http.NewRequest("GET", "http://localhost:8984/articles/3?pretty", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -42,7 +44,8 @@ func TestHandler_GetArticleByIDValidData(t *testing.T) {
   		"aaa",
   		"bbb",
   		"ccc"
-  	]
+  	],
+  	"comment_count": 0
   }`,
 		rr.Body.String(),
 		"handler returned unexpected body")
@@ -217,7 +220,7 @@ func TestHandler_GetArticleByTagNameDateInValidDateInValidTag(t *testing.T) {
 }
 
 func TestHandler_GetArticleByTagNameDate(t *testing.T) {
-	req, err := http.NewRequest("GET", "http://localhost:8984/tag/aaa/20181005", nil)
+	req, err := http.NewRequest("GET", "http://localhost:8984/tag/aaa/20181005?pretty", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -293,7 +296,7 @@ func TestHandler_ArticlesHandlerInValidInput(t *testing.T) {
 }
 
 func TestHandler_ArticlesHandlerValidInput(t *testing.T) {
-	var handler = &Handler{Database{}}
+	var handler = &Handler{database: &Database{}}
 	data := []byte(`{"id":1,"title":"","date":"2018-03-14","body":"Change in climate and vegetation","tags":["world","climate","nature"]}`)
 
 	req, err := http.NewRequest("POST", "http://localhost:8984/articles", bytes.NewBuffer(data))
@@ -315,22 +318,114 @@ func TestHandler_ArticlesHandlerValidInput(t *testing.T) {
 			status, http.StatusCreated)
 	}
 
+	// Check the body is the created article.
+	var created Article
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "2018-03-14", created.Date)
+	assert.Equal(t, "Change in climate and vegetation", created.Body)
+
 	// check header
-	if location := rr.Header().Get("Location"); location == "articles/"+strconv.Itoa(handler.database.articlesID) {
+	if location := rr.Header().Get("Location"); location != "http://localhost:8984/articles/"+strconv.Itoa(created.ID) {
 		t.Errorf("handler returned wrong Location : got %v want %v",
-			location, "articles/"+strconv.Itoa(handler.database.articlesID))
+			location, "http://localhost:8984/articles/"+strconv.Itoa(created.ID))
 	}
-
-	// Check the header message.
-	if !bytes.Equal(rr.Body.Bytes(), []byte("Added the article successfully...")) {
-		t.Errorf("handler returned unexpected body: got %v want \n%v",
-			rr.Body.Bytes(),
-			[]byte("Added the article successfully..."))
+	if etag := rr.Header().Get("ETag"); etag != articleETag(created) {
+		t.Errorf("handler returned wrong ETag : got %v want %v", etag, articleETag(created))
 	}
 }
 
+func TestHandler_ArticlesHandlerReturnsCreated(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "one", Tags: []string{"aaa"}})
+	data := `{"title":"Two","date":"2018-10-05","body":"two","tags":["bbb"]}`
+
+	req, _ := http.NewRequest("POST", "http://localhost:8984/articles", strings.NewReader(data))
+	req.SetBasicAuth("test", "password")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com")
+	req.RemoteAddr = "10.1.2.3:40000"
+	trustedProxies = loadProxies("10.0.0.0/8")
+	defer func() { trustedProxies = loadProxies("") }()
+	rr := httptest.NewRecorder()
+	newRouter(h).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "https://api.example.com/articles/2", rr.Header().Get("Location"))
+	assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	var created Article
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	assert.Equal(t, 2, created.ID)
+	assert.Equal(t, "Two", created.Title)
+	assert.Equal(t, articleETag(created), rr.Header().Get("ETag"))
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost:8984/feeds/articles.rss", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "evil.example.com")
+	req.RemoteAddr = "192.0.2.7:40000"
+
+	// the forwarded headers of other clients are not honoured.
+	assert.Equal(t, "http://localhost:8984/tags/a%20b", absoluteURL(req, "/tags/a%20b"))
+	trustedProxies = loadProxies("10.0.0.0/8, 192.0.2.7, bogus")
+	defer func() { trustedProxies = loadProxies("") }()
+	assert.Equal(t, 2, len(trustedProxies))
+	assert.Equal(t, "https://evil.example.com/tags/a%20b", absoluteURL(req, "/tags/a%20b"))
+
+	// a base url comes before any header.
+	baseURL = loadBaseURL("https://api.example.com/blog/")
+	defer func() { baseURL = nil }()
+	assert.Equal(t, "https://api.example.com/blog/tags/c%2Fd", absoluteURL(req, "/tags/c%2Fd"))
+	assert.Nil(t, loadBaseURL("api.example.com"))
+}
+
+func TestHandler_GetArticleByIDIfNoneMatch(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "one", Tags: []string{"aaa"}})
+
+	rr := serve(h, "GET", "http://localhost:8984/articles/1", "")
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8984/articles/1", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	rr = serveRequest(h, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestHandler_UpdateArticleIfMatch(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "one", Tags: []string{"aaa"}})
+	etag := serve(h, "GET", "http://localhost:8984/articles/1", "").Header().Get("ETag")
+	data := `{"title":"One edited","date":"2018-10-04","body":"one","tags":["aaa"]}`
+
+	req, _ := http.NewRequest("PUT", "http://localhost:8984/articles/1", strings.NewReader(data))
+	req.Header.Set("If-Match", etag)
+	rr := serveRequest(h, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+	assert.Contains(t, rr.Body.String(), `"version":1`)
+
+	// a second editor still holding the old ETag.
+	req, _ = http.NewRequest("PUT", "http://localhost:8984/articles/1", strings.NewReader(data))
+	req.Header.Set("If-Match", etag)
+	rr = serveRequest(h, req)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	req, _ = http.NewRequest("DELETE", "http://localhost:8984/articles/1", nil)
+	req.Header.Set("If-Match", etag)
+	rr = serveRequest(h, req)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = serve(h, "DELETE", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = serve(h, "GET", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
 func TestHandler_ArticlesHandlerDuplicateInput(t *testing.T) {
-	var handler = &Handler{Database{}}
+	var handler = &Handler{database: &Database{}}
 	data := []byte(`{"id":1,"title":"","date":"2018-03-14","body":"Change in climate and vegetation","tags":["world","climate","nature"]}`)
 
 	req, err := http.NewRequest("POST", "http://localhost:8984/articles", bytes.NewBuffer(data))
@@ -347,17 +442,21 @@ func TestHandler_ArticlesHandlerDuplicateInput(t *testing.T) {
 	h.ServeHTTP(rr, req)
 
 	// Check the status code is what we expect.
-	if status := rr.Code; status != http.StatusInternalServerError {
+	if status := rr.Code; status != http.StatusConflict {
 		t.Errorf("handler returned wrong status code: got %v want %v",
-			status, http.StatusInternalServerError)
+			status, http.StatusConflict)
 	}
 
-	// Check the error message.
-	if !bytes.Equal(rr.Body.Bytes(), []byte("Info: Article already exists in database, 15\n")) {
-		t.Errorf("handler returned unexpected body: got %v want \n%v",
-			rr.Body.Bytes(),
-			[]byte("Info: Article already exists in database, 15\n"))
+	// check header
+	if location := rr.Header().Get("Location"); location != "http://localhost:8984/articles/15" {
+		t.Errorf("handler returned wrong Location : got %v want %v", location, "http://localhost:8984/articles/15")
 	}
+
+	// Check the error message.
+	assert.Equal(t,
+		`{"error":"Info: Article already exists in database, 15","ID":15,"near":false}`,
+		rr.Body.String(),
+		"handler returned unexpected body")
 }
 
 func TestHandler_DeleteArticleInValidData(t *testing.T) {
@@ -419,3 +518,37 @@ func TestHandler_DeleteArticleValidInput(t *testing.T) {
 			[]byte("Deleted article successfully..."))
 	}
 }
+
+// newMemoryHandler returns a handler on an in-memory store seeded with articles.
+func newMemoryHandler(t *testing.T, articles ...Article) *Handler {
+	h := &Handler{database: NewMemoryStore(), blobs: NewLocalBlobStore(t.TempDir())}
+	for _, a := range articles {
+		if _, err := h.database.AddArticle(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return h
+}
+
+// serve runs the request through the router of h with valid credentials.
+func serve(h *Handler, method, url, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	return serveRequest(h, req)
+}
+
+// serveRequest runs req through the router of h with valid credentials.
+func serveRequest(h *Handler, req *http.Request) *httptest.ResponseRecorder {
+	req.SetBasicAuth("test", "password")
+	rr := httptest.NewRecorder()
+	newRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+// serveAs runs the request through the router of h as user, who needs an account.
+func serveAs(h *Handler, user, method, url, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.SetBasicAuth(user, accounts[user].password)
+	rr := httptest.NewRecorder()
+	newRouter(h).ServeHTTP(rr, req)
+	return rr
+}
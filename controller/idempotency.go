@@ -0,0 +1,163 @@
+// Idempotency-Key support so retried POSTs are answered once.
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const maxIdempotencyKeyLen = 255
+
+// idempotencyTTL is how long responses are kept for replay, set with the IDEMPOTENCY_TTL env
+// variable as a duration eg: 12h.
+var idempotencyTTL = envDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+
+// envDuration reads a duration env variable, returning def when it is not set or invalid.
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Println("Error: invalid", name, "env variable, using", def)
+		return def
+	}
+	return d
+}
+
+// perRequestHeaders are response headers of one request only, they are neither kept nor replayed.
+var perRequestHeaders = map[string]bool{"X-Request-Id": true}
+
+// replayHeader returns the response headers kept for a replay.
+func replayHeader(header http.Header) map[string][]string {
+	kept := make(map[string][]string)
+	for name, values := range header {
+		if !perRequestHeaders[http.CanonicalHeaderKey(name)] {
+			kept[name] = values
+		}
+	}
+	return kept
+}
+
+// responseRecorder passes the response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// requestHash identifies a request by method, url and body.
+func requestHash(r *http.Request, body []byte) string {
+	sum := sha256.New()
+	io.WriteString(sum, r.Method+" "+r.URL.RequestURI()+"\n")
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// Idempotent answers requests carrying an Idempotency-Key header only once: a retry with the same key
+// and request replays the recorded response, the same key with another request is rejected with 422.
+// Keys are scoped to the authenticated user.
+func (h *Handler) Idempotent(pass authHandler) authHandler {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			pass(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			http.Error(w, "Error: Idempotency-Key is too long.", http.StatusBadRequest)
+			return
+		}
+
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+		if err != nil {
+			http.Error(w, "Error: reading the request.", http.StatusUnprocessableEntity)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		user, _, _ := r.BasicAuth()
+		now := time.Now().UTC()
+		record := IdempotencyRecord{
+			Key:         user + ":" + key,
+			RequestHash: requestHash(r, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyTTL),
+		}
+
+		existing, reserved, err := h.database.ReserveIdempotencyKey(record)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Println(err.Error())
+			return
+		}
+		if !reserved {
+			replayIdempotent(w, existing, record.RequestHash)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		pass(rec, r)
+
+		// server errors are not kept so the client can retry.
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			err = h.database.ReleaseIdempotencyKey(record.Key)
+		} else {
+			record.Completed = true
+			record.Status = rec.status
+			record.Header = replayHeader(w.Header())
+			record.Body = rec.body.Bytes()
+			err = h.database.CompleteIdempotencyKey(record)
+		}
+		if err != nil {
+			log.Println("Error: recording Idempotency-Key", key, err)
+		}
+	}
+}
+
+// replayIdempotent answers a retry from the record kept for its key.
+func replayIdempotent(w http.ResponseWriter, record IdempotencyRecord, hash string) {
+	if record.RequestHash != hash {
+		http.Error(w, "Error: Idempotency-Key was already used for a different request.", http.StatusUnprocessableEntity)
+		return
+	}
+	if !record.Completed {
+		w.Header().Set("Retry-After", strconv.Itoa(1))
+		http.Error(w, "Error: A request with this Idempotency-Key is still in progress.", http.StatusConflict)
+		return
+	}
+
+	// the retry keeps its own X-Request-ID, records kept before it was left out included.
+	for name, values := range replayHeader(record.Header) {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// postWithKey posts data to /articles with an Idempotency-Key header.
+func postWithKey(h *Handler, key, data string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "http://localhost:8984/articles", strings.NewReader(data))
+	req.SetBasicAuth("test", "password")
+	req.Header.Set("Idempotency-Key", key)
+	rr := httptest.NewRecorder()
+	newRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestHandler_IdempotencyKeyReplay(t *testing.T) {
+	h := newMemoryHandler(t)
+	data := `{"title":"Global Warming","date":"2018-10-04","body":"Change in climate","tags":["world"]}`
+
+	first := postWithKey(h, "key-1", data)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	retry := postWithKey(h, "key-1", data)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Header().Get("Location"), retry.Header().Get("Location"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	// every request keeps its own id.
+	assert.NotEqual(t, first.Header().Get("X-Request-ID"), retry.Header().Get("X-Request-ID"))
+	assert.NotEmpty(t, retry.Header().Get("X-Request-ID"))
+
+	articles, _ := h.database.ListArticles(ArticleFilter{})
+	assert.Len(t, articles, 1)
+}
+
+func TestMemoryStore_IdempotencyKeysExpire(t *testing.T) {
+	m := NewMemoryStore()
+	past := time.Now().Add(-time.Minute)
+	m.ReserveIdempotencyKey(IdempotencyRecord{Key: "old", ExpiresAt: past})
+	m.ReserveIdempotencyKey(IdempotencyRecord{Key: "kept", ExpiresAt: time.Now().Add(time.Hour)})
+
+	_, reserved, _ := m.ReserveIdempotencyKey(IdempotencyRecord{Key: "kept", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.False(t, reserved)
+	assert.Equal(t, 1, len(m.idempotency))
+	_, reserved, _ = m.ReserveIdempotencyKey(IdempotencyRecord{Key: "old", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.True(t, reserved)
+}
+
+func TestHandler_IdempotencyKeyReusedWithOtherBody(t *testing.T) {
+	h := newMemoryHandler(t)
+
+	rr := postWithKey(h, "key-1", `{"title":"One","date":"2018-10-04","body":"one","tags":["world"]}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = postWithKey(h, "key-1", `{"title":"Two","date":"2018-10-04","body":"two","tags":["world"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, "Error: Idempotency-Key was already used for a different request.\n", rr.Body.String())
+}
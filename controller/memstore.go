@@ -0,0 +1,695 @@
+// Package implements an in-memory article store, used when no mongo server is available.
+package controller
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"awesomeProject/errors"
+)
+
+type MemoryStore struct {
+	mutex sync.RWMutex
+
+	articlesID int
+	articles   map[int]Article
+	index      *invertedIndex
+
+	idempotency map[string]IdempotencyRecord
+	revisions   map[int][]Revision
+	audit       []AuditEntry
+
+	authorsID int
+	authors   map[int]Author
+
+	commentsID int
+	comments   map[int]Comment
+
+	attachmentsID int
+	attachments   map[int]Attachment
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		articles:    make(map[int]Article),
+		index:       newInvertedIndex(),
+		idempotency: make(map[string]IdempotencyRecord),
+		revisions:   make(map[int][]Revision),
+		authors:     make(map[int]Author),
+		comments:    make(map[int]Comment),
+		attachments: make(map[int]Attachment),
+	}
+}
+
+// copyArticle returns a with its own tags slice so callers can not modify the store.
+func copyArticle(a Article) Article {
+	a.Tags = append([]string(nil), a.Tags...)
+	a.OldSlugs = append([]string(nil), a.OldSlugs...)
+	return a
+}
+
+// sortedIDs returns the ids of all stored articles in insertion order.
+func (m *MemoryStore) sortedIDs() []int {
+	ids := make([]int, 0, len(m.articles))
+	for id := range m.articles {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// findDuplicate applies the same content hash and simhash rules as checkDuplicate for the mongo store.
+func (m *MemoryStore) findDuplicate(data Article, exclude int) *DuplicateError {
+	ids := m.sortedIDs()
+	for _, id := range ids {
+		if id != exclude && m.articles[id].ContentHash == data.ContentHash {
+			return &DuplicateError{ID: id}
+		}
+	}
+	for _, id := range ids {
+		if id == exclude {
+			continue
+		}
+		if distance, near := isNearDuplicate(data.SimHash, m.articles[id].SimHash); near {
+			return &DuplicateError{ID: id, Near: true, Distance: distance}
+		}
+	}
+	return nil
+}
+
+// AddArticle inserts the record into memory.
+func (m *MemoryStore) AddArticle(data Article) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	fingerprint(&data)
+	describe(&data)
+	if err := m.findDuplicate(data, 0); err != nil {
+		return -1, err
+	}
+	assignSlug(&data, Article{}, m.slugTaken(0))
+
+	m.articlesID += 1
+	data.ID = m.articlesID
+	m.articles[data.ID] = copyArticle(data)
+	m.index.add(data)
+	log.Println("Added new Article with id :", data.ID)
+	return data.ID, nil
+}
+
+// GetArticleByID retrives the article with 'id'.
+func (m *MemoryStore) GetArticleByID(id int) (Article, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.DeletedAt != nil {
+		return Article{}, errors.New("Error: Failed to retrive the article with ID, not found")
+	}
+	return copyArticle(a), nil
+}
+
+// slugTaken reports whether an article other than 'id' has or had a slug, those in trash included.
+func (m *MemoryStore) slugTaken(id int) func(string) (bool, error) {
+	return func(slug string) (bool, error) {
+		for _, a := range m.articles {
+			if a.ID != id && (a.Slug == slug || contains(a.OldSlugs, slug)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// GetArticleBySlug retrives the article having 'slug' now or before.
+func (m *MemoryStore) GetArticleBySlug(slug string) (Article, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, id := range m.sortedIDs() {
+		a := m.articles[id]
+		if a.DeletedAt == nil && (a.Slug == slug || contains(a.OldSlugs, slug)) {
+			return copyArticle(a), nil
+		}
+	}
+	return Article{}, errors.New("Error: Failed to retrive the article with slug, not found")
+}
+
+// GetArticleByTagDate retrieves array of Articles that matches the 'tag' and 'date'.
+func (m *MemoryStore) GetArticleByTagDate(tagStr, dateStr string) (ArticlesArr, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var result ArticlesArr
+	filter := ArticleFilter{Tag: tagStr, From: dateStr, To: dateStr}
+	for _, id := range m.sortedIDs() {
+		if filter.matches(m.articles[id]) {
+			result = append(result, copyArticle(m.articles[id]))
+		}
+	}
+	if len(result) == 0 {
+		// keep the message the mongo store returns for an empty result.
+		return result, errors.New("Error: Failed to retrive the articles for date&Tag, <nil>")
+	}
+	return result, nil
+}
+
+// DeleteArticle deletes the article matching data.
+func (m *MemoryStore) DeleteArticle(data Article) (Article, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// the article to delete is the one with the same content.
+	fingerprint(&data)
+	id := -1
+	for _, a := range m.articles {
+		if a.ContentHash == data.ContentHash {
+			id = a.ID
+		}
+	}
+	if id < 0 {
+		return Article{}, errors.New("Error: Data enter not found in database, not found")
+	}
+	deleted := copyArticle(m.articles[id])
+	m.trash(id)
+	return deleted, nil
+}
+
+// trash marks the article as deleted, dropping its fingerprints like the mongo store does.
+func (m *MemoryStore) trash(id int) {
+	a := m.articles[id]
+	now := time.Now().UTC()
+	a.DeletedAt = &now
+	a.ContentHash, a.SimHash, a.SimHashBands = "", 0, nil
+	a.Version += 1
+	m.articles[id] = a
+	m.index.remove(id)
+	log.Println("Successfully moved to trash the article with id: ", id)
+}
+
+// UpdateArticle replaces the content of the article with data.ID.
+func (m *MemoryStore) UpdateArticle(data Article) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, ok := m.articles[data.ID]
+	if !ok || a.DeletedAt != nil {
+		return errors.New("Error: Failed to update the article with ID, not found")
+	}
+	if a.Version != data.Version {
+		return errVersionConflict
+	}
+	fingerprint(&data)
+	describe(&data)
+	if err := m.findDuplicate(data, data.ID); err != nil {
+		return err
+	}
+	assignSlug(&data, a, m.slugTaken(a.ID))
+
+	a.Title, a.Date, a.Body, a.Tags, a.Format = data.Title, data.Date, data.Body, data.Tags, data.Format
+	a.ContentHash, a.SimHash, a.SimHashBands = data.ContentHash, data.SimHash, data.SimHashBands
+	a.WordCount, a.ReadingTime, a.Excerpt, a.FirstImage = data.WordCount, data.ReadingTime, data.Excerpt, data.FirstImage
+	a.Slug, a.OldSlugs = data.Slug, data.OldSlugs
+	a.Version += 1
+	m.articles[a.ID] = copyArticle(a)
+	m.index.remove(a.ID)
+	m.index.add(a)
+	log.Println("Updated the article with id :", a.ID)
+	return nil
+}
+
+// DeleteArticleByID moves the article with 'id' at 'version' to trash.
+func (m *MemoryStore) DeleteArticleByID(id, version int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.DeletedAt != nil {
+		return errors.New("Error: Failed to retrive the article with ID, not found")
+	}
+	if a.Version != version {
+		return errVersionConflict
+	}
+	m.trash(id)
+	return nil
+}
+
+// UpdateStatus sets the status of the article with 'id' at 'version'.
+func (m *MemoryStore) UpdateStatus(id, version int, status string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.DeletedAt != nil {
+		return errors.New("Error: Failed to update the article with ID, not found")
+	}
+	if a.Version != version {
+		return errVersionConflict
+	}
+	a.Status = status
+	if status == statusPublished {
+		now := time.Now().UTC()
+		a.PublishedAt, a.PublishAt = &now, nil
+	}
+	a.Version += 1
+	m.articles[id] = a
+	log.Println("Article", id, "is now", status)
+	return nil
+}
+
+// SchedulePublish sets or clears the publish_at time of the article with 'id' at 'version'.
+func (m *MemoryStore) SchedulePublish(id, version int, at *time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.DeletedAt != nil {
+		return errors.New("Error: Failed to update the article with ID, not found")
+	}
+	if a.Version != version {
+		return errVersionConflict
+	}
+	if at != nil {
+		utc := at.UTC()
+		at = &utc
+	}
+	a.PublishAt = at
+	a.Version += 1
+	m.articles[id] = a
+	return nil
+}
+
+// RestoreArticle takes the article with 'id' out of trash.
+func (m *MemoryStore) RestoreArticle(id int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.DeletedAt == nil {
+		return errors.New("Error: Failed to retrive the article from trash, not found")
+	}
+	fingerprint(&a)
+	describe(&a)
+	if err := m.findDuplicate(a, id); err != nil {
+		return err
+	}
+	a.DeletedAt = nil
+	a.Version += 1
+	m.articles[id] = a
+	m.index.add(a)
+	log.Println("Restored from trash the article with id: ", id)
+	return nil
+}
+
+// PurgeArticle removes the article with 'id' from trash together with its revisions.
+func (m *MemoryStore) PurgeArticle(id int) (Article, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.DeletedAt == nil {
+		return Article{}, errors.New("Error: Failed to retrive the article from trash, not found")
+	}
+	delete(m.articles, id)
+	delete(m.revisions, id)
+	for cid, c := range m.comments {
+		if c.ArticleID == id {
+			delete(m.comments, cid)
+		}
+	}
+	log.Println("Purged the article with id: ", id)
+	return a, nil
+}
+
+// Search scores the articles through the inverted index.
+func (m *MemoryStore) Search(query SearchQuery) ([]SearchHit, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var hits []SearchHit
+	for id, score := range m.index.search(query) {
+		a := m.articles[id]
+		if query.matchesFilters(a) {
+			hits = append(hits, SearchHit{Article: copyArticle(a), Score: score})
+		}
+	}
+	sortHits(hits)
+	if query.Limit > 0 && len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+// ListArticles returns the articles matching filter.
+func (m *MemoryStore) ListArticles(filter ArticleFilter) (ArticlesArr, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := ArticlesArr{}
+	for _, id := range m.sortedIDs() {
+		if filter.matches(m.articles[id]) {
+			result = append(result, copyArticle(m.articles[id]))
+		}
+	}
+	if filter.Newest {
+		sort.SliceStable(result, func(i, j int) bool { return newer(result[i], result[j]) })
+	}
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, nil
+}
+
+// ListTags counts the articles matching filter of every tag, most used tag first.
+func (m *MemoryStore) ListTags(filter ArticleFilter) ([]TagCount, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	counts := make(map[string]int)
+	for _, a := range m.articles {
+		if !filter.matches(a) {
+			continue
+		}
+		for _, tag := range unique(a.Tags) {
+			counts[tag] += 1
+		}
+	}
+	return sortTagCounts(counts), nil
+}
+
+// ReplaceTag swaps the tag on every article holding the lock, so each update is atomic.
+func (m *MemoryStore) ReplaceTag(from, to string) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	updated := 0
+	for id, a := range m.articles {
+		if hasTag(a, from) {
+			a.Tags = replaceTag(a.Tags, from, to)
+			a.Version += 1
+			m.articles[id] = a
+			updated += 1
+		}
+	}
+	return updated, nil
+}
+
+// ReserveIdempotencyKey stores record as pending unless an unexpired record exists, expired records
+// are dropped on the way as mongo does with its expiry index.
+func (m *MemoryStore) ReserveIdempotencyKey(record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for key, kept := range m.idempotency {
+		if !kept.ExpiresAt.After(now) {
+			delete(m.idempotency, key)
+		}
+	}
+	if existing, ok := m.idempotency[record.Key]; ok {
+		return existing, false, nil
+	}
+	m.idempotency[record.Key] = record
+	return record, true, nil
+}
+
+// CompleteIdempotencyKey stores the response of a reserved key.
+func (m *MemoryStore) CompleteIdempotencyKey(record IdempotencyRecord) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.idempotency[record.Key] = record
+	return nil
+}
+
+// ReleaseIdempotencyKey drops a reserved key.
+func (m *MemoryStore) ReleaseIdempotencyKey(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.idempotency, key)
+	return nil
+}
+
+// AddRevision appends the revision to the history of its article.
+func (m *MemoryStore) AddRevision(revision Revision) (Revision, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	revision.Rev = len(m.revisions[revision.ArticleID]) + 1
+	revision.Article = copyArticle(revision.Article)
+	m.revisions[revision.ArticleID] = append(m.revisions[revision.ArticleID], revision)
+	return revision, nil
+}
+
+// ListRevisions returns the revisions of an article, oldest first.
+func (m *MemoryStore) ListRevisions(articleID int) ([]Revision, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return append([]Revision{}, m.revisions[articleID]...), nil
+}
+
+// GetRevision returns revision 'rev' of an article.
+func (m *MemoryStore) GetRevision(articleID, rev int) (Revision, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	revisions := m.revisions[articleID]
+	if rev < 1 || rev > len(revisions) {
+		return Revision{}, errors.New("Error: Failed to retrive the revision, not found")
+	}
+	return revisions[rev-1], nil
+}
+
+// AddAuditEntry appends the entry to the audit log.
+func (m *MemoryStore) AddAuditEntry(entry AuditEntry) (AuditEntry, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entry.Seq = len(m.audit) + 1
+	m.audit = append(m.audit, entry)
+	return entry, nil
+}
+
+// ListAuditEntries returns the entries matching filter, oldest first.
+func (m *MemoryStore) ListAuditEntries(filter AuditFilter) ([]AuditEntry, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := []AuditEntry{}
+	for _, e := range m.audit {
+		if filter.matches(e) {
+			result = append(result, e)
+		}
+	}
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+	return result, nil
+}
+
+// AddAuthor stores the author under the next id.
+func (m *MemoryStore) AddAuthor(author Author) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, a := range m.authors {
+		if a.User == author.User {
+			return -1, errAuthorExists
+		}
+	}
+	m.authorsID += 1
+	author.ID = m.authorsID
+	m.authors[author.ID] = author
+	log.Println("Added new Author with id :", author.ID)
+	return author.ID, nil
+}
+
+// GetAuthor retrives the author with 'id'.
+func (m *MemoryStore) GetAuthor(id int) (Author, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	author, ok := m.authors[id]
+	if !ok {
+		return Author{}, errors.New("Error: Failed to retrive the author with ID, not found")
+	}
+	return author, nil
+}
+
+// GetAuthorByUser retrives the author profile of 'user'.
+func (m *MemoryStore) GetAuthorByUser(user string) (Author, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, a := range m.authors {
+		if a.User == user {
+			return a, nil
+		}
+	}
+	return Author{}, errors.New("Error: Failed to retrive the author of the user, not found")
+}
+
+// ListAuthors returns every author ordered by id.
+func (m *MemoryStore) ListAuthors() ([]Author, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := []Author{}
+	for _, a := range m.authors {
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// UpdateAuthor replaces the profile fields of the author with author.ID.
+func (m *MemoryStore) UpdateAuthor(author Author) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, ok := m.authors[author.ID]
+	if !ok {
+		return errors.New("Error: Failed to update the author with ID, not found")
+	}
+	a.Name, a.Bio, a.Contact, a.AvatarURL = author.Name, author.Bio, author.Contact, author.AvatarURL
+	m.authors[a.ID] = a
+	return nil
+}
+
+// DeleteAuthor deletes the author with 'id'.
+func (m *MemoryStore) DeleteAuthor(id int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.authors[id]; !ok {
+		return errors.New("Error: Failed to delete the author with ID, not found")
+	}
+	delete(m.authors, id)
+	log.Println("Deleted the author with id: ", id)
+	return nil
+}
+
+// AddComment stores the comment under the next id.
+func (m *MemoryStore) AddComment(comment Comment) (Comment, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.commentsID += 1
+	comment.ID = m.commentsID
+	m.comments[comment.ID] = comment
+	return comment, nil
+}
+
+// GetComment retrives the comment with 'id'.
+func (m *MemoryStore) GetComment(id int) (Comment, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	comment, ok := m.comments[id]
+	if !ok {
+		return Comment{}, errors.New("Error: Failed to retrive the comment with ID, not found")
+	}
+	return comment, nil
+}
+
+// ListComments returns every comment of an article ordered by id.
+func (m *MemoryStore) ListComments(articleID int) ([]Comment, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := []Comment{}
+	for _, c := range m.comments {
+		if c.ArticleID == articleID {
+			result = append(result, c)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// UpdateComment replaces the changeable fields of the comment with comment.ID.
+func (m *MemoryStore) UpdateComment(comment Comment) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	c, ok := m.comments[comment.ID]
+	if !ok {
+		return errors.New("Error: Failed to update the comment with ID, not found")
+	}
+	c.Body, c.Status, c.Deleted, c.UpdatedAt = comment.Body, comment.Status, comment.Deleted, comment.UpdatedAt
+	m.comments[c.ID] = c
+	return nil
+}
+
+// CountComments counts the approved comments of the articles.
+func (m *MemoryStore) CountComments(articleIDs ...int) (map[int]int, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	wanted := make(map[int]bool)
+	for _, id := range articleIDs {
+		wanted[id] = true
+	}
+	counts := make(map[int]int)
+	for _, c := range m.comments {
+		if wanted[c.ArticleID] && c.Status == commentApproved && !c.Deleted {
+			counts[c.ArticleID] += 1
+		}
+	}
+	return counts, nil
+}
+
+// AddAttachment stores the attachment under the next id.
+func (m *MemoryStore) AddAttachment(attachment Attachment) (Attachment, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.attachmentsID += 1
+	attachment.ID = m.attachmentsID
+	m.attachments[attachment.ID] = attachment
+	return attachment, nil
+}
+
+// GetAttachment retrives the attachment with 'id'.
+func (m *MemoryStore) GetAttachment(id int) (Attachment, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	attachment, ok := m.attachments[id]
+	if !ok {
+		return Attachment{}, errors.New("Error: Failed to retrive the attachment with ID, not found")
+	}
+	return attachment, nil
+}
+
+// ListAttachments returns the attachments of an article ordered by id.
+func (m *MemoryStore) ListAttachments(articleID int) ([]Attachment, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := []Attachment{}
+	for _, a := range m.attachments {
+		if a.ArticleID == articleID {
+			result = append(result, a)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// DeleteAttachment deletes the attachment with 'id'.
+func (m *MemoryStore) DeleteAttachment(id int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.attachments[id]; !ok {
+		return errors.New("Error: Failed to delete the attachment with ID, not found")
+	}
+	delete(m.attachments, id)
+	return nil
+}
@@ -0,0 +1,134 @@
+// Metadata computed from the body of the articles.
+package controller
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// readingSpeed is in words per minute for space separated languages and in characters per minute for
+	// Chinese and Japanese, which are counted a character per word.
+	readingSpeed     = envInt("READING_SPEED", 200)
+	readingSpeedCJK  = envInt("READING_SPEED_CJK", 500)
+	excerptMaxLength = envInt("EXCERPT_LENGTH", 200)
+)
+
+// blockElements end a line of the text of a body.
+var blockElements = map[string]bool{
+	"blockquote": true, "br": true, "dd": true, "div": true, "dt": true, "figcaption": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "hr": true, "li": true, "p": true, "pre": true, "td": true,
+	"th": true, "tr": true,
+}
+
+// an excerpt may end after a sentence, the full stops of Chinese and Japanese are not followed by a space.
+const (
+	sentenceEnds    = ".!?"
+	cjkSentenceEnds = "。！？"
+)
+
+// describe sets word count, reading time, excerpt and first image of the article from its body.
+func describe(a *Article) {
+	text, image := bodyText(*a)
+	words, cjk := countWords(text)
+	a.WordCount = words + cjk
+	a.ReadingTime = 0
+	if a.WordCount > 0 {
+		// rounded up, an article takes at least a minute.
+		a.ReadingTime = (words*readingSpeedCJK + cjk*readingSpeed + readingSpeed*readingSpeedCJK - 1) / (readingSpeed * readingSpeedCJK)
+	}
+	a.Excerpt = excerpt(text, excerptMaxLength)
+	a.FirstImage = image
+}
+
+// bodyText returns the text of the rendered body with a line per block and the url of its first image.
+func bodyText(a Article) (string, string) {
+	rendered, _, err := renderBody(a)
+	if err != nil {
+		return a.Body, ""
+	}
+
+	var text strings.Builder
+	image := ""
+	tokenizer := html.NewTokenizer(strings.NewReader(rendered))
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			return text.String(), image
+		}
+		token := tokenizer.Token()
+		switch tt {
+		case html.TextToken:
+			text.WriteString(token.Data)
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			if blockElements[token.Data] {
+				text.WriteString("\n")
+			}
+			if token.Data == "img" && tt != html.EndTagToken && image == "" {
+				for _, attr := range token.Attr {
+					if attr.Key == "src" {
+						image = attr.Val
+					}
+				}
+			}
+		}
+	}
+}
+
+// isCJK reports whether r is written without spaces between words.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// countWords returns the number of space separated words and of Chinese and Japanese characters of text.
+func countWords(text string) (int, int) {
+	words, cjk := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk += 1
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			if !inWord {
+				words += 1
+			}
+			inWord = true
+		case r == '\'' || r == '’' || r == '-':
+			// part of words like don't and well-known.
+		default:
+			inWord = false
+		}
+	}
+	return words, cjk
+}
+
+// excerpt returns the start of text up to max characters, ending after a sentence when one ends in the
+// second half, else before a word; text without spaces like Chinese is cut anywhere.
+func excerpt(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	cut := max
+	for i := max; i > max/2; i-- {
+		end := runes[i-1]
+		if strings.ContainsRune(cjkSentenceEnds, end) || (strings.ContainsRune(sentenceEnds, end) && runes[i] == ' ') {
+			return string(runes[:i])
+		}
+	}
+	for i := max; i > max/2; i-- {
+		if runes[i] == ' ' {
+			cut = i
+			break
+		}
+		if isCJK(runes[i-1]) || isCJK(runes[i]) {
+			break
+		}
+	}
+	return strings.TrimSpace(string(runes[:cut])) + "…"
+}
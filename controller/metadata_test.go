@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountWords(t *testing.T) {
+	words, cjk := countWords("Don't panic, it's well-known: 42 apples.")
+	assert.Equal(t, 6, words)
+	assert.Equal(t, 0, cjk)
+
+	words, cjk = countWords("東京は大きい city")
+	assert.Equal(t, 1, words)
+	assert.Equal(t, 6, cjk)
+}
+
+func TestExcerpt(t *testing.T) {
+	assert.Equal(t, "Short text.", excerpt("  Short\n text. ", 20))
+	assert.Equal(t, "One sentence here.", excerpt("One sentence here. Another one follows", 30))
+	assert.Equal(t, "Words are never cut…", excerpt("Words are never cut in half", 20))
+	assert.Equal(t, "今日は晴れです。", excerpt("今日は晴れです。明日は雨が降るでしょう", 12))
+	assert.Equal(t, "明日は雨が…", excerpt("明日は雨が降るでしょう", 5))
+}
+
+func TestDescribe(t *testing.T) {
+	a := Article{Format: formatMarkdown, Body: "# Title\n\n![cat](/img/cat.png) " + strings.Repeat("word ", 399) + "\n\n![dog](/img/dog.png)"}
+	describe(&a)
+	assert.Equal(t, 400, a.WordCount)
+	assert.Equal(t, 2, a.ReadingTime)
+	assert.Equal(t, "/img/cat.png", a.FirstImage)
+	assert.True(t, strings.HasPrefix(a.Excerpt, "Title word word"))
+
+	a = Article{}
+	describe(&a)
+	assert.Equal(t, Article{}, a)
+}
+
+func TestHandler_ArticleMetadata(t *testing.T) {
+	h := newMemoryHandler(t)
+
+	rr := serve(h, "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"<p>Three short words</p><img src=\"https://example.com/a.png\">","tags":["aaa"],"format":"html"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	rr = serve(h, "GET", "http://localhost:8984/articles/1", "")
+	var article Article
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, 3, article.WordCount)
+	assert.Equal(t, 1, article.ReadingTime)
+	assert.Equal(t, "Three short words", article.Excerpt)
+	assert.Equal(t, "https://example.com/a.png", article.FirstImage)
+
+	// recomputed on update.
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"One","date":"2018-10-04","body":"Just two","tags":["aaa"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, 2, article.WordCount)
+	assert.Equal(t, "Just two", article.Excerpt)
+
+	article = Article{}
+	rr = serve(h, "GET", "http://localhost:8984/articles/1", "")
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "", article.FirstImage)
+}
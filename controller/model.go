@@ -1,5 +1,10 @@
 package controller
 
+import (
+	"encoding/xml"
+	"time"
+)
+
 // Each Article representation.
 type Article struct {
 	ID    int      `bson:"_id"`
@@ -7,6 +12,48 @@ type Article struct {
 	Date  string   `json:"date"`
 	Body  string   `json:"body"`
 	Tags  []string `json:"tags"`
+
+	// Slug is the unique name of the article in urls, made from its title; the slugs it had before
+	// still lead to it, see slug.go.
+	Slug     string   `json:"slug,omitempty" bson:"slug,omitempty"`
+	OldSlugs []string `json:"-" bson:"old_slugs,omitempty"`
+
+	// Format of the body: plain (default), markdown or html, see render.go.
+	Format string `json:"format,omitempty" bson:"format,omitempty"`
+
+	// AuthorID is the author profile of the user who created the article, see authors.go.
+	AuthorID int `json:"author_id,omitempty" bson:"author_id,omitempty"`
+
+	// computed from the body on every write, see describe; ReadingTime is in minutes.
+	WordCount   int    `json:"word_count,omitempty" bson:"word_count,omitempty"`
+	ReadingTime int    `json:"reading_time,omitempty" bson:"reading_time,omitempty"`
+	Excerpt     string `json:"excerpt,omitempty" bson:"excerpt,omitempty"`
+	FirstImage  string `json:"first_image,omitempty" bson:"first_image,omitempty"`
+
+	// Sanitized reports the markup removed from an html article on create and update, see sanitizeArticle.
+	Sanitized map[string]SanitizeReport `json:"sanitized,omitempty" bson:"-"`
+
+	// CommentCount is the number of approved comments, filled in on every article answered.
+	CommentCount int `json:"comment_count" bson:"-"`
+
+	// Status is the workflow state of the article, see workflow.go; articles stored before it
+	// existed have none and count as published.
+	Status      string     `json:"status,omitempty" bson:"status,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty" bson:"published_at,omitempty"`
+	// PublishAt is when the scheduler publishes the article, see scheduler.go.
+	PublishAt *time.Time `json:"publish_at,omitempty" bson:"publish_at,omitempty"`
+
+	// Version is incremented on every update, see Store.UpdateArticle.
+	Version int `json:"version,omitempty" bson:"version,omitempty"`
+
+	// fingerprints of title and body for duplicate detection, see fingerprint.
+	ContentHash string `json:"-" bson:"content_hash,omitempty"`
+	SimHash     int64  `json:"-" bson:"simhash,omitempty"`
+	// SimHashBands are the lookup keys of SimHash, see simHashBands.
+	SimHashBands []int `json:"-" bson:"simhash_bands,omitempty"`
+
+	// DeletedAt is set while the article is in trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
 }
 
 // response model for tagName&Date query.
@@ -17,5 +64,336 @@ type ArticleTagDate struct {
 	Related_tags []string `json:"related_tags"`
 }
 
+// response model for a POST of an article that already exists.
+type Conflict struct {
+	Error string `json:"error"`
+	ID    int    `json:"ID"`
+	Near  bool   `json:"near"`
+}
+
 // Articles is array of Article objects.
 type ArticlesArr []Article
+
+// SearchQuery is the parsed form of a full-text search request.
+type SearchQuery struct {
+	Text    string
+	Terms   []string
+	Phrases []string
+	Tags    []string
+	From    string
+	To      string
+	Limit   int
+	// Published leaves out articles that are not published.
+	Published bool
+}
+
+// SearchHit is one article matching a search with its relevance.
+type SearchHit struct {
+	Article `bson:",inline"`
+	Score   float64 `json:"score" bson:"score"`
+	Snippet string  `json:"snippet" bson:"-"`
+}
+
+// response model for full-text search.
+type SearchResult struct {
+	Query   string      `json:"query"`
+	Count   int         `json:"count"`
+	Results []SearchHit `json:"results"`
+}
+
+// TagCount is a tag with the number of articles carrying it.
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// response model for tag listing.
+type TagList struct {
+	Count int        `json:"count"`
+	Tags  []TagCount `json:"tags"`
+}
+
+// response model for a single tag.
+type TagDetails struct {
+	Tag          string     `json:"tag"`
+	Count        int        `json:"count"`
+	FirstDate    string     `json:"first_date"`
+	LastDate     string     `json:"last_date"`
+	Articles     []int      `json:"articles"`
+	Related_tags []TagCount `json:"related_tags"`
+}
+
+// request model for renaming and merging tags.
+type TagUpdate struct {
+	Name string `json:"name"`
+	Into string `json:"into"`
+}
+
+// response model for rename, merge and delete of a tag.
+type TagChange struct {
+	Tag             string `json:"tag"`
+	Into            string `json:"into,omitempty"`
+	ArticlesUpdated int    `json:"articles_updated"`
+}
+
+// TagEdge links two tags appearing together on 'weight' articles.
+type TagEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Weight int    `json:"weight"`
+}
+
+// response model for the tag co-occurrence graph.
+type TagGraph struct {
+	From  string     `json:"from,omitempty"`
+	To    string     `json:"to,omitempty"`
+	Nodes []TagCount `json:"nodes"`
+	Edges []TagEdge  `json:"edges"`
+}
+
+// TrendingTag is the growth of one tag versus the previous window.
+type TrendingTag struct {
+	Tag      string  `json:"tag"`
+	Count    int     `json:"count"`
+	Previous int     `json:"previous"`
+	Growth   float64 `json:"growth"`
+	Buckets  []int   `json:"buckets"`
+}
+
+// response model for trending tags, Buckets of each tag follow Periods.
+type TrendingTags struct {
+	Window  string        `json:"window"`
+	Start   string        `json:"start"`
+	End     string        `json:"end"`
+	Periods []string      `json:"periods"`
+	Tags    []TrendingTag `json:"tags"`
+}
+
+// RelatedArticle is an article scored against another one.
+type RelatedArticle struct {
+	ID        int      `json:"ID"`
+	Title     string   `json:"title"`
+	Date      string   `json:"date"`
+	Tags      []string `json:"tags"`
+	Score     float64  `json:"score"`
+	TagScore  float64  `json:"tag_score"`
+	DateScore float64  `json:"date_score"`
+	TextScore float64  `json:"text_score"`
+}
+
+// response model for related articles.
+type RelatedArticles struct {
+	ID      int              `json:"ID"`
+	Count   int              `json:"count"`
+	Related []RelatedArticle `json:"related"`
+}
+
+// IdempotencyRecord is the response kept for an Idempotency-Key.
+type IdempotencyRecord struct {
+	Key         string              `bson:"_id"`
+	RequestHash string              `bson:"request_hash"`
+	Completed   bool                `bson:"completed"`
+	Status      int                 `bson:"status"`
+	Header      map[string][]string `bson:"header"`
+	Body        []byte              `bson:"body"`
+	CreatedAt   time.Time           `bson:"created_at"`
+	ExpiresAt   time.Time           `bson:"expires_at"`
+}
+
+// Revision is an immutable copy of an article kept after every change.
+type Revision struct {
+	ArticleID int       `json:"article_id" bson:"article_id"`
+	Rev       int       `json:"rev" bson:"rev"`
+	Author    string    `json:"author" bson:"author"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+	Action    string    `json:"action" bson:"action"`
+	// Diff holds the changed lines versus the previous content, see lineDiff.
+	Diff    []string `json:"diff" bson:"diff"`
+	Article Article  `json:"article" bson:"article"`
+}
+
+// RevisionSummary is a revision without its content.
+type RevisionSummary struct {
+	Rev       int       `json:"rev"`
+	Author    string    `json:"author"`
+	Timestamp time.Time `json:"timestamp"`
+	Action    string    `json:"action"`
+}
+
+// response model for the revisions of an article.
+type RevisionList struct {
+	ID        int               `json:"ID"`
+	Count     int               `json:"count"`
+	Revisions []RevisionSummary `json:"revisions"`
+}
+
+// response model for an article in trash.
+type TrashedArticle struct {
+	ID        int       `json:"ID"`
+	Title     string    `json:"title"`
+	Date      string    `json:"date"`
+	Tags      []string  `json:"tags"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// response model for the trash.
+type TrashList struct {
+	Count     int              `json:"count"`
+	Retention string           `json:"retention"`
+	Articles  []TrashedArticle `json:"articles"`
+}
+
+// AuditEntry records one change made to the articles, see Handler.audit.
+type AuditEntry struct {
+	Seq       int       `json:"seq" bson:"_id"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+	User      string    `json:"user" bson:"user"`
+	Action    string    `json:"action" bson:"action"`
+	ArticleID int       `json:"article_id" bson:"article_id"`
+	RequestID string    `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	// Before and After are the article as it was and as it is, missing for a create and a purge.
+	Before *Article `json:"before,omitempty" bson:"before,omitempty"`
+	After  *Article `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+// response model for the audit log.
+type AuditLog struct {
+	Count   int          `json:"count"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+// request model to move an article to another workflow state.
+type StatusChange struct {
+	Status string `json:"status"`
+}
+
+// request model to schedule the publication of an article.
+type Schedule struct {
+	PublishAt time.Time `json:"publish_at"`
+}
+
+// response model for an article waiting to be published.
+type ScheduledArticle struct {
+	ID        int       `json:"ID"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	PublishAt time.Time `json:"publish_at"`
+}
+
+// response model for the publication queue.
+type ScheduleQueue struct {
+	Count    int                `json:"count"`
+	Articles []ScheduledArticle `json:"articles"`
+}
+
+// Author is the public profile of a user writing articles.
+type Author struct {
+	ID        int    `json:"ID" bson:"_id"`
+	User      string `json:"user" bson:"user"`
+	Name      string `json:"name" bson:"name"`
+	Bio       string `json:"bio,omitempty" bson:"bio,omitempty"`
+	Contact   string `json:"contact,omitempty" bson:"contact,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty" bson:"avatar_url,omitempty"`
+}
+
+// response model for the list of authors.
+type AuthorList struct {
+	Count   int      `json:"count"`
+	Authors []Author `json:"authors"`
+}
+
+// response model for the articles of an author.
+type AuthorArticles struct {
+	Author   Author      `json:"author"`
+	Count    int         `json:"count"`
+	Articles ArticlesArr `json:"articles"`
+}
+
+// Comment on an article, a reply when it has a ParentID.
+type Comment struct {
+	ID        int        `json:"ID" bson:"_id"`
+	ArticleID int        `json:"article_id" bson:"article_id"`
+	ParentID  int        `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	User      string     `json:"user" bson:"user"`
+	Body      string     `json:"body" bson:"body"`
+	Status    string     `json:"status" bson:"status"`
+	Deleted   bool       `json:"deleted,omitempty" bson:"deleted,omitempty"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// CommentThread is a comment with its replies.
+type CommentThread struct {
+	Comment
+	Replies []CommentThread `json:"replies"`
+}
+
+// response model for a page of the comments of an article.
+type CommentPage struct {
+	ArticleID int             `json:"article_id"`
+	Total     int             `json:"total"`
+	Page      int             `json:"page"`
+	Limit     int             `json:"limit"`
+	Comments  []CommentThread `json:"comments"`
+}
+
+// Attachment is a file uploaded for an article, its content is kept in a BlobStore under Key.
+type Attachment struct {
+	ID          int       `json:"ID" bson:"_id"`
+	ArticleID   int       `json:"article_id" bson:"article_id"`
+	FileName    string    `json:"file_name" bson:"file_name"`
+	ContentType string    `json:"content_type" bson:"content_type"`
+	Size        int64     `json:"size" bson:"size"`
+	User        string    `json:"user" bson:"user"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	URL         string    `json:"url" bson:"-"`
+	Key         string    `json:"-" bson:"key"`
+}
+
+// response model for the attachments of an article.
+type AttachmentList struct {
+	ArticleID   int          `json:"article_id"`
+	Count       int          `json:"count"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// TOCEntry is a heading of a rendered article body.
+type TOCEntry struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+// response model for an article rendered with ?render=html.
+type RenderedArticle struct {
+	Article
+	HTML string     `json:"html"`
+	TOC  []TOCEntry `json:"toc"`
+}
+
+// SitemapURL is a page listed in a sitemap.
+type SitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// response model for a sitemap file.
+type URLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []SitemapURL `xml:"url"`
+}
+
+// SitemapRef is a sitemap file listed in a sitemap index.
+type SitemapRef struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// response model for a sitemap index.
+type SitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []SitemapRef `xml:"sitemap"`
+}
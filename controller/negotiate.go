@@ -0,0 +1,748 @@
+// Content negotiation of the response and request formats.
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"awesomeProject/errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v2"
+)
+
+// mediaFormats maps the media types understood to their format.
+var mediaFormats = map[string]string{
+	"application/json":        "json",
+	"application/xml":         "xml",
+	"text/xml":                "xml",
+	"application/yaml":        "yaml",
+	"application/x-yaml":      "yaml",
+	"text/yaml":               "yaml",
+	"text/x-yaml":             "yaml",
+	"text/csv":                "csv",
+	"application/msgpack":     "msgpack",
+	"application/x-msgpack":   "msgpack",
+	"application/vnd.msgpack": "msgpack",
+}
+
+// formatTypes is the content type answered for each format.
+var formatTypes = map[string]string{
+	"json":    "application/json; charset=utf-8",
+	"xml":     "application/xml; charset=utf-8",
+	"yaml":    "application/yaml; charset=utf-8",
+	"csv":     "text/csv; charset=utf-8",
+	"msgpack": "application/msgpack",
+}
+
+// suffixFormats maps the structured syntax suffixes of application types, eg: application/problem+json,
+// to their format.
+var suffixFormats = map[string]string{
+	"+json": "json",
+	"+xml":  "xml",
+	"+yaml": "yaml",
+}
+
+// acceptedFormats returns the formats of the Accept header, preferred first; no header accepts json.
+// xhtml and xml ranked below html are how browsers ask for web pages, not for the xml of the API.
+func acceptedFormats(accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return []string{"json"}
+	}
+
+	type choice struct {
+		format string
+		q      float64
+	}
+	choices := []choice{}
+	html := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		format := mediaFormats[mediaType]
+		switch {
+		case mediaType == "*/*" || mediaType == "application/*":
+			format = "json"
+		case mediaType == "text/*":
+			format = "csv"
+		case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+			html = q
+		case format == "" && strings.HasPrefix(mediaType, "application/"):
+			if plus := strings.LastIndex(mediaType, "+"); plus > 0 {
+				format = suffixFormats[mediaType[plus:]]
+			}
+		}
+		if format != "" && q > 0 {
+			choices = append(choices, choice{format: format, q: q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+
+	formats := []string{}
+	for _, c := range choices {
+		if c.format == "xml" && c.q < html {
+			continue
+		}
+		formats = append(formats, c.format)
+	}
+	return formats
+}
+
+// representationETag returns etag, the tag of the json representation, for the representation the
+// response is negotiated to, eg: "abc-xml" for xml; pretty printed json is one of its own too.
+func representationETag(w http.ResponseWriter, etag string) string {
+	formats, pretty := negotiation(w)
+	suffix := ""
+	for _, format := range formats {
+		// csv only holds lists.
+		if format != "csv" {
+			if format != "json" {
+				suffix = "-" + format
+			}
+			break
+		}
+	}
+	if pretty {
+		suffix += "-pretty"
+	}
+	if suffix == "" {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + suffix + `"`
+}
+
+// negotiatedWriter carries the formats the client accepts to writeJsonStatus.
+type negotiatedWriter struct {
+	http.ResponseWriter
+	formats []string
+	pretty  bool
+}
+
+func (nw *negotiatedWriter) Unwrap() http.ResponseWriter {
+	return nw.ResponseWriter
+}
+
+// negotiation returns the formats accepted by the request the response is for; responses written
+// without Negotiate are compact json.
+func negotiation(w http.ResponseWriter) ([]string, bool) {
+	for {
+		switch writer := w.(type) {
+		case *negotiatedWriter:
+			return writer.formats, writer.pretty
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = writer.Unwrap()
+		default:
+			return []string{"json"}, false
+		}
+	}
+}
+
+// bodyFormat returns the format of the request body by its Content-Type, json when it has none.
+// Forms are read as json too as that is what curl -d sends them as.
+func bodyFormat(r *http.Request) (string, bool) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return "json", true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	if mediaType == "application/x-www-form-urlencoded" || strings.HasSuffix(mediaType, "+json") {
+		return "json", true
+	}
+	format, ok := mediaFormats[mediaType]
+	return format, ok && format != "csv"
+}
+
+// Negotiate makes writeJson answer in the format of the Accept header, pretty printed with 'pretty',
+// and rejects request bodies whose Content-Type can not be read with 415 Unsupported Media Type.
+// Requests changing data are answered 406 Not Acceptable before they change anything when no format
+// of their Accept header can hold the response, which is never a list; reads are answered 406 by
+// writeNegotiated as feeds, the sitemap and attachments have formats of their own.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		formats := acceptedFormats(r.Header.Get("Accept"))
+		if r.Method != "GET" && r.Method != "HEAD" {
+			if r.ContentLength != 0 {
+				mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				// uploads are multipart, see UploadAttachments.
+				if _, ok := bodyFormat(r); !ok && mediaType != "multipart/form-data" {
+					http.Error(w, "Error: Unsupported Content-Type, use json, xml, yaml or msgpack.", http.StatusUnsupportedMediaType)
+					return
+				}
+			}
+			acceptable := false
+			for _, format := range formats {
+				acceptable = acceptable || format != "csv"
+			}
+			if !acceptable {
+				http.Error(w, "Error: Not Acceptable, use json, xml, yaml or msgpack.", http.StatusNotAcceptable)
+				return
+			}
+		}
+		_, pretty := r.URL.Query()["pretty"]
+		next.ServeHTTP(&negotiatedWriter{ResponseWriter: w, formats: formats, pretty: pretty}, r)
+	})
+}
+
+// writeNegotiated answers data in the first accepted format that can hold it, csv only holds lists.
+func writeNegotiated(w http.ResponseWriter, status int, data interface{}) {
+	formats, pretty := negotiation(w)
+	w.Header().Add("Vary", "Accept")
+
+	bJson, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, format := range formats {
+		var body []byte
+		var err error
+		switch format {
+		case "json":
+			body = bJson
+			if pretty {
+				body, _ = prettyprint(bJson)
+			}
+		case "xml":
+			body, err = encodeXML(rootName(data), orderedJSON(bJson), pretty)
+		case "yaml":
+			body, err = yaml.Marshal(yamlValue(orderedJSON(bJson)))
+		case "csv":
+			body, err = encodeCSV(orderedJSON(bJson))
+		case "msgpack":
+			body, err = encodeMsgpack(orderedJSON(bJson))
+		}
+		if err == errNotAList {
+			continue
+		}
+		if err != nil {
+			panic(err)
+		}
+		w.Header().Add("Content-Type", formatTypes[format])
+		w.WriteHeader(status)
+		w.Write(body)
+		return
+	}
+	http.Error(w, "Error: Not Acceptable, use json, xml, yaml, msgpack or csv for lists.", http.StatusNotAcceptable)
+}
+
+// orderedField is a member of a json object, orderedMap keeps them in order.
+type orderedField struct {
+	key   string
+	value interface{}
+}
+
+type orderedMap []orderedField
+
+// orderedJSON decodes json into maps keeping the order of their members, numbers are json.Number.
+func orderedJSON(data []byte) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := orderedValue(decoder)
+	if err != nil {
+		// data was made by json.Marshal.
+		panic(err)
+	}
+	return value
+}
+
+func orderedValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := orderedMap{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := orderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, orderedField{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := orderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+// rootName returns the xml root element of data, its type name eg: tagList for TagList.
+func rootName(data interface{}) string {
+	t := reflect.TypeOf(data)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" || t.Kind() == reflect.Slice {
+		return "response"
+	}
+	name := []rune(t.Name())
+	name[0] = unicode.ToLower(name[0])
+	return string(name)
+}
+
+// xmlName matches the keys usable as element names, others are written as <entry key="...">.
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// encodeXML writes value as element 'name': members of objects as child elements and items of arrays
+// as <item> elements.
+func encodeXML(name string, value interface{}, pretty bool) ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&out)
+	if pretty {
+		encoder.Indent("", "  ")
+	}
+	if err := xmlElement(encoder, name, value); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+func xmlElement(encoder *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !xmlName.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml") {
+		start = xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}}
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case orderedMap:
+		for _, field := range v {
+			if err := xmlElement(encoder, field.key, field.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := xmlElement(encoder, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// yamlValue turns the ordered json into values yaml.v2 writes in the same order.
+func yamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case orderedMap:
+		slice := yaml.MapSlice{}
+		for _, field := range v {
+			slice = append(slice, yaml.MapItem{Key: field.key, Value: yamlValue(field.value)})
+		}
+		return slice
+	case []interface{}:
+		items := []interface{}{}
+		for _, item := range v {
+			items = append(items, yamlValue(item))
+		}
+		return items
+	case json.Number:
+		return numberValue(v)
+	}
+	return value
+}
+
+// numberValue returns n as an int64 when it is whole, else as a float64.
+func numberValue(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// errNotAList is returned when data without a list is asked for as csv.
+var errNotAList = errors.New("Error: Only lists can be answered as csv.")
+
+// csvRows returns the list of objects of the value: the value itself or the only member of an object
+// holding a list of objects, eg: the tags of a TagList.
+func csvRows(value interface{}) ([]interface{}, bool) {
+	isRows := func(v interface{}) bool {
+		items, ok := v.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, item := range items {
+			if _, ok := item.(orderedMap); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	if isRows(value) {
+		return value.([]interface{}), true
+	}
+
+	var rows []interface{}
+	found := 0
+	if object, ok := value.(orderedMap); ok {
+		for _, field := range object {
+			if items, ok := field.value.([]interface{}); ok && len(items) > 0 && isRows(items) {
+				rows = items
+				found += 1
+			}
+		}
+	}
+	return rows, found == 1
+}
+
+// encodeCSV writes the list of value with a header of the members of its objects; values that are
+// not scalars are written as json.
+func encodeCSV(value interface{}) ([]byte, error) {
+	rows, ok := csvRows(value)
+	if !ok {
+		return nil, errNotAList
+	}
+
+	columns := []string{}
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for _, field := range row.(orderedMap) {
+			if !seen[field.key] {
+				seen[field.key] = true
+				columns = append(columns, field.key)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	writer.Write(columns)
+	for _, row := range rows {
+		cells := make(map[string]string)
+		for _, field := range row.(orderedMap) {
+			cells[field.key] = csvCell(field.value)
+		}
+		record := []string{}
+		for _, column := range columns {
+			record = append(record, cells[column])
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return out.Bytes(), writer.Error()
+}
+
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	}
+	b, _ := json.Marshal(plainValue(value))
+	return string(b)
+}
+
+// plainValue turns the ordered json into maps and slices, losing the order of the members.
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case orderedMap:
+		object := make(map[string]interface{})
+		for _, field := range v {
+			object[field.key] = plainValue(field.value)
+		}
+		return object
+	case []interface{}:
+		items := []interface{}{}
+		for _, item := range v {
+			items = append(items, plainValue(item))
+		}
+		return items
+	}
+	return value
+}
+
+// encodeMsgpack writes the ordered json as MessagePack.
+func encodeMsgpack(value interface{}) ([]byte, error) {
+	var out bytes.Buffer
+	err := msgpackValue(msgpack.NewEncoder(&out), value)
+	return out.Bytes(), err
+}
+
+func msgpackValue(encoder *msgpack.Encoder, value interface{}) error {
+	switch v := value.(type) {
+	case orderedMap:
+		if err := encoder.EncodeMapLen(len(v)); err != nil {
+			return err
+		}
+		for _, field := range v {
+			if err := encoder.EncodeString(field.key); err != nil {
+				return err
+			}
+			if err := msgpackValue(encoder, field.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if err := encoder.EncodeArrayLen(len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := msgpackValue(encoder, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		return encoder.Encode(numberValue(v))
+	}
+	return encoder.Encode(value)
+}
+
+// decodeBody decodes the request body into v by its Content-Type; every format is turned into json
+// first so that the json names of the fields apply to all of them.
+func decodeBody(r *http.Request, body []byte, v interface{}) error {
+	format, ok := bodyFormat(r)
+	if !ok {
+		return errors.New("Error: Unsupported Content-Type.")
+	}
+
+	var value interface{}
+	var err error
+	switch format {
+	case "json":
+		return json.Unmarshal(body, v)
+	case "yaml":
+		err = yaml.Unmarshal(body, &value)
+	case "msgpack":
+		value, err = msgpack.NewDecoder(bytes.NewReader(body)).DecodeInterface()
+	case "xml":
+		if value, err = decodeXML(body); err == nil {
+			value = coerce(value, reflect.TypeOf(v))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	bJson, err := json.Marshal(jsonValue(value))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bJson, v)
+}
+
+// jsonValue turns the maps decoded from yaml and msgpack into maps json can write.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{})
+		for key, item := range v {
+			object[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return object
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+	}
+	return value
+}
+
+// decodeXML reads xml as encodeXML writes it: elements with child elements are objects, unless all
+// children are <item>s which makes them arrays; the text of the others is a string.
+func decodeXML(body []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("Error: The xml body is empty.")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return xmlValue(decoder, start)
+		}
+	}
+}
+
+func xmlValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var text bytes.Buffer
+	object := map[string]interface{}{}
+	items := []interface{}{}
+	children := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			value, err := xmlValue(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			children += 1
+			name := t.Name.Local
+			for _, attr := range t.Attr {
+				if name == "entry" && attr.Name.Local == "key" {
+					name = attr.Value
+				}
+			}
+			if name == "item" {
+				items = append(items, value)
+				continue
+			}
+			if existing, ok := object[name]; ok {
+				// repeated elements make a list.
+				list, isList := existing.([]interface{})
+				if !isList {
+					list = []interface{}{existing}
+				}
+				object[name] = append(list, value)
+			} else {
+				object[name] = value
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			switch {
+			case children == 0:
+				return text.String(), nil
+			case len(items) == children:
+				return items, nil
+			}
+			return object, nil
+		}
+	}
+}
+
+// coerce converts the strings read from xml to the numbers and booleans of the fields of t they are
+// decoded into, and single values to lists where t has a slice.
+func coerce(value interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		fields := jsonFields(t)
+		for key, item := range object {
+			if field, ok := fields[key]; ok {
+				object[key] = coerce(item, field)
+			}
+		}
+		return object
+	case reflect.Map:
+		if object, ok := value.(map[string]interface{}); ok {
+			for key, item := range object {
+				object[key] = coerce(item, t.Elem())
+			}
+		}
+		return value
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return value
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			if value == "" {
+				return []interface{}{}
+			}
+			items = []interface{}{value}
+		}
+		for i, item := range items {
+			items[i] = coerce(item, t.Elem())
+		}
+		return items
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if s, ok := value.(string); ok {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return json.Number(strings.TrimSpace(s))
+			}
+		}
+	case reflect.Bool:
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				return b
+			}
+		}
+	}
+	return value
+}
+
+// jsonFields maps the json names of the fields of struct t to their type, embedded structs included.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embedded, fieldType := range jsonFields(field.Type) {
+				if _, ok := fields[embedded]; !ok {
+					fields[embedded] = fieldType
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
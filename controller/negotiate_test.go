@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v2"
+)
+
+// serveWith runs the request through the router of h with the given headers.
+func serveWith(h *Handler, method, url, body string, header map[string]string) *bytes.Buffer {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	return serveRequest(h, req).Body
+}
+
+func TestAcceptedFormats(t *testing.T) {
+	assert.Equal(t, []string{"json"}, acceptedFormats(""))
+	assert.Equal(t, []string{"xml", "json"}, acceptedFormats("text/html, application/xml, */*;q=0.8"))
+	assert.Equal(t, []string{"yaml", "msgpack"}, acceptedFormats("application/x-msgpack;q=0.5, text/yaml"))
+	assert.Equal(t, []string{}, acceptedFormats("image/png, application/json;q=0"))
+	assert.Equal(t, []string{"json", "yaml"}, acceptedFormats("application/problem+json, application/x.foo+yaml;q=0.5"))
+	assert.Equal(t, []string{}, acceptedFormats("application/xhtml+xml, image/svg+xml"))
+	// what browsers send for pages.
+	assert.Equal(t, []string{"json"}, acceptedFormats("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"))
+}
+
+func TestHandler_NegotiateResponse(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa", "bbb"}},
+		Article{Title: "Two", Date: "2018-10-05", Body: "second", Tags: []string{"aaa"}},
+	)
+
+	rr := serve(h, "GET", "http://localhost:8984/tags", "")
+	assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `{"count":2,"tags":[{"tag":"aaa","count":2},{"tag":"bbb","count":1}]}`, rr.Body.String())
+	assert.Contains(t, serve(h, "GET", "http://localhost:8984/tags?pretty", "").Body.String(), "\n  \t\"count\": 2,")
+
+	body := serveWith(h, "GET", "http://localhost:8984/tags", "", map[string]string{"Accept": "application/xml"})
+	assert.Equal(t, xmlHeader()+`<tagList><count>2</count><tags><item><tag>aaa</tag><count>2</count></item><item><tag>bbb</tag><count>1</count></item></tags></tagList>`+"\n", body.String())
+
+	body = serveWith(h, "GET", "http://localhost:8984/tags", "", map[string]string{"Accept": "application/yaml"})
+	assert.Equal(t, "count: 2\ntags:\n- tag: aaa\n  count: 2\n- tag: bbb\n  count: 1\n", body.String())
+
+	body = serveWith(h, "GET", "http://localhost:8984/tags", "", map[string]string{"Accept": "text/csv"})
+	assert.Equal(t, "tag,count\naaa,2\nbbb,1\n", body.String())
+
+	var tags TagList
+	body = serveWith(h, "GET", "http://localhost:8984/tags", "", map[string]string{"Accept": "application/msgpack"})
+	decoder := msgpack.NewDecoder(body)
+	decoder.SetCustomStructTag("json")
+	assert.Nil(t, decoder.Decode(&tags))
+	assert.Equal(t, 2, tags.Count)
+
+	// a single article is no list, csv falls back to the next format accepted.
+	req, _ := http.NewRequest("GET", "http://localhost:8984/articles/1", nil)
+	req.Header.Set("Accept", "text/csv")
+	rr = serveRequest(h, req)
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	req.Header.Set("Accept", "text/csv, application/yaml;q=0.5")
+	rr = serveRequest(h, req)
+	assert.Equal(t, "application/yaml; charset=utf-8", rr.Header().Get("Content-Type"))
+	var article map[string]interface{}
+	yaml.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "One", article["title"])
+}
+
+func TestHandler_NegotiateRequest(t *testing.T) {
+	h := newMemoryHandler(t)
+
+	xmlBody := `<article><title>1984</title><date>2018-10-04</date><body>xml</body><tags><item>aaa</item></tags></article>`
+	body := serveWith(h, "POST", "http://localhost:8984/articles", xmlBody, map[string]string{"Content-Type": "application/xml"})
+	var article Article
+	json.Unmarshal(body.Bytes(), &article)
+	assert.Equal(t, "1984", article.Title)
+	assert.Equal(t, []string{"aaa"}, article.Tags)
+
+	yamlBody := "title: Two\ndate: 2018-10-05\nbody: yaml\ntags: [bbb, ccc]\n"
+	body = serveWith(h, "PUT", "http://localhost:8984/articles/1", yamlBody, map[string]string{"Content-Type": "application/x-yaml"})
+	json.Unmarshal(body.Bytes(), &article)
+	assert.Equal(t, "Two", article.Title)
+	assert.Equal(t, []string{"bbb", "ccc"}, article.Tags)
+
+	packed, _ := msgpack.Marshal(map[string]interface{}{"title": "Three", "date": "2018-10-06", "body": "msgpack", "tags": []string{"ddd"}})
+	body = serveWith(h, "POST", "http://localhost:8984/articles", string(packed), map[string]string{"Content-Type": "application/msgpack"})
+	json.Unmarshal(body.Bytes(), &article)
+	assert.Equal(t, 2, article.ID)
+	assert.Equal(t, "Three", article.Title)
+
+	req, _ := http.NewRequest("POST", "http://localhost:8984/articles", strings.NewReader("title=Four"))
+	req.Header.Set("Content-Type", "text/plain")
+	assert.Equal(t, http.StatusUnsupportedMediaType, serveRequest(h, req).Code)
+}
+
+func TestHandler_NotAcceptableBeforeWriting(t *testing.T) {
+	h := newMemoryHandler(t)
+
+	req, _ := http.NewRequest("POST", "http://localhost:8984/articles", strings.NewReader(`{"title":"One","date":"2018-10-04","body":"first","tags":["aaa"]}`))
+	req.Header.Set("Accept", "text/html")
+	assert.Equal(t, http.StatusNotAcceptable, serveRequest(h, req).Code)
+	req, _ = http.NewRequest("POST", "http://localhost:8984/articles", strings.NewReader(`{"title":"One","date":"2018-10-04","body":"first","tags":["aaa"]}`))
+	req.Header.Set("Accept", "text/csv")
+	assert.Equal(t, http.StatusNotAcceptable, serveRequest(h, req).Code)
+
+	articles, _ := h.database.ListArticles(ArticleFilter{})
+	assert.Equal(t, 0, len(articles))
+}
+
+func TestHandler_NegotiatedETag(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}})
+
+	etag := serve(h, "GET", "http://localhost:8984/articles/1", "").Header().Get("ETag")
+	req, _ := http.NewRequest("GET", "http://localhost:8984/articles/1", nil)
+	req.Header.Set("Accept", "application/xml")
+	rr := serveRequest(h, req)
+	xmlETag := rr.Header().Get("ETag")
+	assert.Equal(t, strings.TrimSuffix(etag, `"`)+`-xml"`, xmlETag)
+
+	req.Header.Set("If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, serveRequest(h, req).Code)
+	req.Header.Set("If-None-Match", xmlETag)
+	assert.Equal(t, http.StatusNotModified, serveRequest(h, req).Code)
+
+	// the xml tag holds for updates as the json one does.
+	req, _ = http.NewRequest("PUT", "http://localhost:8984/articles/1", strings.NewReader(`{"title":"One","date":"2018-10-04","body":"changed","tags":["aaa"]}`))
+	req.Header.Set("If-Match", xmlETag)
+	assert.Equal(t, http.StatusOK, serveRequest(h, req).Code)
+	req, _ = http.NewRequest("PUT", "http://localhost:8984/articles/1", strings.NewReader(`{"title":"One","date":"2018-10-04","body":"again","tags":["aaa"]}`))
+	req.Header.Set("If-Match", xmlETag)
+	assert.Equal(t, http.StatusPreconditionFailed, serveRequest(h, req).Code)
+}
+
+func TestDecodeXMLCoerce(t *testing.T) {
+	var tags TagList
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Content-Type", "text/xml")
+	err := decodeBody(req, []byte(`<tagList><count>2</count><tags><item><tag>a b</tag><count>1</count></item></tags></tagList>`), &tags)
+	assert.Nil(t, err)
+	assert.Equal(t, TagList{Count: 2, Tags: []TagCount{{Tag: "a b", Count: 1}}}, tags)
+}
+
+func xmlHeader() string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+}
@@ -0,0 +1,177 @@
+// Related-article recommendations.
+package controller
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"awesomeProject/errors"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// weights of the shared tags, date proximity and text similarity in the final score.
+	relatedTagWeight  = 0.5
+	relatedDateWeight = 0.2
+	relatedTextWeight = 0.3
+
+	// articles this many days apart get half the date score.
+	relatedDateHalfLife = 30.0
+
+	defaultRelatedLimit = 5
+	maxRelatedLimit     = 50
+)
+
+// jaccard returns the size of the intersection over the size of the union of two tag sets.
+func jaccard(a, b []string) float64 {
+	set := make(map[string]bool)
+	for _, t := range a {
+		set[t] = true
+	}
+	shared, union := 0, len(set)
+	for _, t := range unique(b) {
+		if set[t] {
+			shared += 1
+		} else {
+			union += 1
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// dateProximity is 1 for articles of the same day and decreases with the days in between.
+func dateProximity(a, b string) float64 {
+	da, errA := time.Parse(dateLayout, a)
+	db, errB := time.Parse(dateLayout, b)
+	if errA != nil || errB != nil {
+		return 0
+	}
+	days := math.Abs(da.Sub(db).Hours() / 24)
+	return 1 / (1 + days/relatedDateHalfLife)
+}
+
+// tfidfVectors returns the tf-idf weighted term vector of title and body of every article.
+func tfidfVectors(articles ArticlesArr) map[int]map[string]float64 {
+	terms := make(map[int]map[string]float64)
+	df := make(map[string]int)
+	for _, a := range articles {
+		counts := make(map[string]float64)
+		for _, t := range keywords(tokenize(titleText(a))) {
+			counts[t] += titleWeight
+		}
+		for _, t := range keywords(tokenize(a.Body)) {
+			counts[t] += bodyWeight
+		}
+		for t := range counts {
+			df[t] += 1
+		}
+		terms[a.ID] = counts
+	}
+
+	total := float64(len(articles))
+	for _, counts := range terms {
+		for t, tf := range counts {
+			counts[t] = tf * math.Log(1+total/float64(df[t]))
+		}
+	}
+	return terms
+}
+
+// cosine returns the cosine similarity of two term vectors.
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for t, w := range a {
+		dot += w * b[t]
+		normA += w * w
+	}
+	for _, w := range b {
+		normB += w * w
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// relatedArticles scores every other article of the corpus against the article and returns the best first.
+func relatedArticles(article Article, corpus ArticlesArr) []RelatedArticle {
+	vectors := tfidfVectors(corpus)
+
+	result := []RelatedArticle{}
+	for _, a := range corpus {
+		if a.ID == article.ID {
+			continue
+		}
+		related := RelatedArticle{
+			ID:        a.ID,
+			Title:     a.Title,
+			Date:      a.Date,
+			Tags:      a.Tags,
+			TagScore:  jaccard(article.Tags, a.Tags),
+			DateScore: dateProximity(article.Date, a.Date),
+			TextScore: cosine(vectors[article.ID], vectors[a.ID]),
+		}
+		related.Score = relatedTagWeight*related.TagScore + relatedDateWeight*related.DateScore + relatedTextWeight*related.TextScore
+		// date alone does not make articles related.
+		if related.TagScore == 0 && related.TextScore == 0 {
+			continue
+		}
+		result = append(result, related)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].ID > result[j].ID
+	})
+	return result
+}
+
+// GetRelatedArticles returns the 'limit' articles most related to article 'id' - GET METHOD.
+func (h *Handler) GetRelatedArticles(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Println("Error: getting the product ID, ", err)
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	limit, err := intParam(r.URL.Query().Get("limit"), defaultRelatedLimit)
+	if err != nil || limit <= 0 {
+		http.Error(w, "Error: Invalid limit.", http.StatusUnprocessableEntity)
+		return
+	}
+	if limit > maxRelatedLimit {
+		limit = maxRelatedLimit
+	}
+
+	article, err := h.database.GetArticleByID(articleID)
+	if err == nil && !canRead(r, article) {
+		err = errors.New("Error: Failed to retrive the article with ID, not found")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		log.Println(err.Error())
+		return
+	}
+
+	corpus, err := h.database.ListArticles(ArticleFilter{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	related := relatedArticles(article, readable(r, corpus))
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	writeJson(w, RelatedArticles{ID: article.ID, Count: len(related), Related: related})
+}
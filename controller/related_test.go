@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_GetRelatedArticles(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "Potato chips", Date: "2018-10-04", Body: "Potato chips are great.", Tags: []string{"health", "food"}},
+		Article{Title: "Sugar", Date: "2018-10-05", Body: "Sugar is not great.", Tags: []string{"health", "food"}},
+		Article{Title: "More potato chips", Date: "2016-01-01", Body: "Why potato chips are better than fries.", Tags: []string{"food"}},
+		Article{Title: "Elections", Date: "2018-10-04", Body: "Votes were counted.", Tags: []string{"politics"}},
+	)
+
+	rr := serve(h, "GET", "http://localhost:8984/articles/1/related?limit=2", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var result RelatedArticles
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, 2, result.Count)
+	assert.Equal(t, 2, result.Related[0].ID)
+	assert.Equal(t, 3, result.Related[1].ID)
+	assert.Equal(t, 1.0, result.Related[0].TagScore)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/9/related", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,148 @@
+// Server-side rendering of article bodies to HTML.
+package controller
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	formatPlain    = "plain"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+)
+
+// markdown renders CommonMark with the GitHub extensions; raw HTML in the source is left out.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// validFormat reports whether format is a known body format, empty is plain.
+func validFormat(format string) bool {
+	return format == "" || format == formatPlain || format == formatMarkdown || format == formatHTML
+}
+
+// plainHTML turns plain text into paragraphs, blank lines separating them.
+func plainHTML(text string) string {
+	var out bytes.Buffer
+	for _, paragraph := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i := range lines {
+			lines[i] = html.EscapeString(lines[i])
+		}
+		out.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+	return out.String()
+}
+
+// renderBody returns the body of the article as sanitized HTML with its table of contents.
+func renderBody(a Article) (string, []TOCEntry, error) {
+	var rendered string
+	switch a.Format {
+	case formatMarkdown:
+		var out bytes.Buffer
+		if err := markdown.Convert([]byte(a.Body), &out); err != nil {
+			return "", nil, err
+		}
+		rendered = out.String()
+	case formatHTML:
+		rendered = a.Body
+	default:
+		rendered = plainHTML(a.Body)
+	}
+	return tableOfContents(sanitizeHTML(rendered))
+}
+
+// anchorID turns the text of a heading into an id, eg: "Global Warming!" is "global-warming".
+func anchorID(text string) string {
+	var id strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && id.Len() > 0 {
+				id.WriteByte('-')
+			}
+			id.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if id.Len() == 0 {
+		return "section"
+	}
+	return id.String()
+}
+
+// headingLevel returns 1 to 6 for the heading elements, 0 otherwise.
+func headingLevel(n *html.Node) int {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return int(n.Data[1] - '0')
+	}
+	return 0
+}
+
+// nodeText returns the text inside n.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		text.WriteString(nodeText(c))
+	}
+	return text.String()
+}
+
+// tableOfContents lists the headings of the fragment and gives every heading a unique id to link to.
+func tableOfContents(fragment string) (string, []TOCEntry, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	toc := []TOCEntry{}
+	used := make(map[string]int)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if level := headingLevel(n); level > 0 {
+			text := strings.TrimSpace(nodeText(n))
+			id := anchorID(text)
+			if used[id] += 1; used[id] > 1 {
+				id += "-" + strconv.Itoa(used[id]-1)
+			}
+			attrs := []html.Attribute{{Key: "id", Val: id}}
+			for _, attr := range n.Attr {
+				if attr.Key != "id" {
+					attrs = append(attrs, attr)
+				}
+			}
+			n.Attr = attrs
+			toc = append(toc, TOCEntry{Level: level, Text: text, ID: id})
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	var out bytes.Buffer
+	for _, n := range nodes {
+		walk(n)
+		if err := html.Render(&out, n); err != nil {
+			return "", nil, err
+		}
+	}
+	return out.String(), toc, nil
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	assert.Equal(t, `<p>hi <b>there</b></p>`, sanitizeHTML(`<p onclick="x()">hi <b>there</b><script>alert(1)</script></p>`))
+	assert.Equal(t, `<a>link</a> <a href="/a">ok</a>`, sanitizeHTML(`<a href="javascript:alert(1)">link</a> <a href="/a" target="_blank">ok</a>`))
+	assert.Equal(t, `text`, sanitizeHTML(`<form><blink>text</blink></form><style>p{}</style>`))
+}
+
+func TestRenderBody(t *testing.T) {
+	html, toc, err := renderBody(Article{Format: formatMarkdown, Body: "# Intro\n\nSome *text* <script>x</script>\n\n## Intro\n"})
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<h1 id="intro">Intro</h1>`)
+	assert.Contains(t, html, `<h2 id="intro-1">Intro</h2>`)
+	assert.Contains(t, html, `<em>text</em>`)
+	assert.NotContains(t, html, "script")
+	assert.Equal(t, []TOCEntry{{Level: 1, Text: "Intro", ID: "intro"}, {Level: 2, Text: "Intro", ID: "intro-1"}}, toc)
+
+	html, toc, err = renderBody(Article{Body: "a <b>\nline\n\nnext"})
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>a &lt;b&gt;<br/>\nline</p>\n<p>next</p>\n", html)
+	assert.Empty(t, toc)
+}
+
+func TestHandler_GetArticleRendered(t *testing.T) {
+	h := newMemoryHandler(t)
+
+	rr := serve(h, "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"x","tags":["aaa"],"format":"rtf"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	rr = serve(h, "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"## Part <i>one</i>","tags":["aaa"],"format":"html"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1?render=html", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var rendered RenderedArticle
+	json.Unmarshal(rr.Body.Bytes(), &rendered)
+	assert.Equal(t, "## Part <i>one</i>", rendered.Body)
+	assert.Equal(t, formatHTML, rendered.Format)
+	assert.Equal(t, "## Part <i>one</i>", rendered.HTML)
+	assert.Empty(t, rendered.TOC)
+	assert.NotEqual(t, serve(h, "GET", "http://localhost:8984/articles/1", "").Header().Get("ETag"), rr.Header().Get("ETag"))
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1?render=pdf", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
@@ -0,0 +1,284 @@
+// Article revision history.
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// currentUser returns the name the request was authenticated with.
+func currentUser(r *http.Request) string {
+	user, _, _ := r.BasicAuth()
+	return user
+}
+
+// revisionLines renders the content of an article as the lines diffed between revisions.
+func revisionLines(a Article) []string {
+	if a.ID == 0 {
+		return nil
+	}
+	lines := []string{
+		"title: " + a.Title,
+		"date: " + a.Date,
+		"tags: " + strings.Join(a.Tags, ", "),
+		"body:",
+	}
+	return append(lines, strings.Split(a.Body, "\n")...)
+}
+
+// lineDiff returns the lines of before and after prefixed with "-" when removed, "+" when added
+// and " " when kept, following Myers' diff in linear space: the path through the edit graph is found
+// by splitting it at middle snakes, so memory only grows with the number of lines.
+func lineDiff(before, after []string) []string {
+	diff := []string{}
+	path := diffPath(before, after, 0, 0, len(before), len(after))
+	for i := 1; i < len(path); i++ {
+		x, y := path[i-1][0], path[i-1][1]
+		// between two points: kept lines, at most one edit, kept lines.
+		for x < path[i][0] && y < path[i][1] && before[x] == after[y] {
+			diff = append(diff, " "+before[x])
+			x, y = x+1, y+1
+		}
+		switch {
+		case path[i][0]-x > path[i][1]-y:
+			diff = append(diff, "-"+before[x])
+			x += 1
+		case path[i][0]-x < path[i][1]-y:
+			diff = append(diff, "+"+after[y])
+			y += 1
+		}
+		for ; x < path[i][0]; x, y = x+1, y+1 {
+			diff = append(diff, " "+before[x])
+		}
+	}
+	return diff
+}
+
+// diffPath returns the points of the shortest edit path from (left, top) to (right, bottom), where x
+// indexes a and y indexes b; nil when there is nothing to compare.
+func diffPath(a, b []string, left, top, right, bottom int) [][2]int {
+	start, end, ok := middleSnake(a, b, left, top, right, bottom)
+	if !ok {
+		return nil
+	}
+	head := diffPath(a, b, left, top, start[0], start[1])
+	if head == nil {
+		head = [][2]int{start}
+	}
+	tail := diffPath(a, b, end[0], end[1], right, bottom)
+	if tail == nil {
+		tail = [][2]int{end}
+	}
+	return append(head, tail...)
+}
+
+// middleSnake searches the shortest edit path of the box from both of its corners at once and returns
+// the snake, one edit followed by kept lines, where both searches meet.
+func middleSnake(a, b []string, left, top, right, bottom int) ([2]int, [2]int, bool) {
+	width, height := right-left, bottom-top
+	if width+height == 0 {
+		return [2]int{}, [2]int{}, false
+	}
+	max := (width + height + 1) / 2
+	delta := width - height
+	// forward[k] is the furthest x reached on diagonal k = x-y from the top left, backward[c] the
+	// furthest y reached on diagonal c = k-delta from the bottom right; both are offset by max+1.
+	offset := max + 1
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+	forward[offset+1] = left
+	backward[offset+1] = bottom
+
+	for d := 0; d <= max; d++ {
+		for k := d; k >= -d; k -= 2 {
+			var x, px int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				px = forward[offset+k+1]
+				x = px
+			} else {
+				px = forward[offset+k-1]
+				x = px + 1
+			}
+			y := top + (x - left) - k
+			py := y
+			if d > 0 && x == px {
+				py = y - 1
+			}
+			for x < right && y < bottom && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			forward[offset+k] = x
+			if c := k - delta; delta%2 != 0 && c >= -(d-1) && c <= d-1 && y >= backward[offset+c] {
+				return [2]int{px, py}, [2]int{x, y}, true
+			}
+		}
+		for c := d; c >= -d; c -= 2 {
+			var y, py int
+			if c == -d || (c != d && backward[offset+c-1] > backward[offset+c+1]) {
+				py = backward[offset+c+1]
+				y = py
+			} else {
+				py = backward[offset+c-1]
+				y = py - 1
+			}
+			k := c + delta
+			x := left + (y - top) + k
+			px := x
+			if d > 0 && y == py {
+				px = x + 1
+			}
+			for x > left && y > top && a[x-1] == b[y-1] {
+				x, y = x-1, y-1
+			}
+			backward[offset+c] = y
+			if delta%2 == 0 && k >= -d && k <= d && x <= forward[offset+k] {
+				return [2]int{x, y}, [2]int{px, py}, true
+			}
+		}
+	}
+	// the searches always meet within max steps.
+	panic("middleSnake: no path")
+}
+
+// recordRevision stores the article as it is after a change made by the request.
+// The change itself already happened, so a failure is only logged.
+func (h *Handler) recordRevision(r *http.Request, action string, before, after Article) {
+	revision := Revision{
+		ArticleID: after.ID,
+		Author:    currentUser(r),
+		Timestamp: time.Now().UTC(),
+		Action:    action,
+		Diff:      lineDiff(revisionLines(before), revisionLines(after)),
+		Article:   after,
+	}
+	if _, err := h.database.AddRevision(revision); err != nil {
+		log.Println("Error: recording revision of article", after.ID, err)
+	}
+}
+
+// revisionParams reads the article id and revision number of the url.
+func revisionParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	articleID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return 0, 0, false
+	}
+	rev, err := strconv.Atoi(vars["rev"])
+	if err != nil || rev <= 0 {
+		http.Error(w, "Error: getting the revision number.", http.StatusUnprocessableEntity)
+		return 0, 0, false
+	}
+	return articleID, rev, true
+}
+
+// historyReadable answers 404 unless the caller may read the revisions of article 'id'.
+// Only editors see the history of unpublished articles, those in trash included.
+func (h *Handler) historyReadable(w http.ResponseWriter, r *http.Request) bool {
+	if !publishedOnly(r) {
+		return true
+	}
+	_, ok := h.readableArticle(w, r)
+	return ok
+}
+
+// ListRevisions lists the revisions of article 'id', oldest first - GET METHOD.
+func (h *Handler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return
+	}
+	if !h.historyReadable(w, r) {
+		return
+	}
+
+	revisions, err := h.database.ListRevisions(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	if len(revisions) == 0 {
+		if _, err := h.database.GetArticleByID(articleID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+
+	result := RevisionList{ID: articleID, Count: len(revisions), Revisions: []RevisionSummary{}}
+	for _, rev := range revisions {
+		result.Revisions = append(result.Revisions, RevisionSummary{
+			Rev:       rev.Rev,
+			Author:    rev.Author,
+			Timestamp: rev.Timestamp,
+			Action:    rev.Action,
+		})
+	}
+	writeJson(w, result)
+}
+
+// GetRevision retrives revision 'rev' of article 'id' with its diff and content - GET METHOD.
+func (h *Handler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	articleID, rev, ok := revisionParams(w, r)
+	if !ok || !h.historyReadable(w, r) {
+		return
+	}
+
+	revision, err := h.database.GetRevision(articleID, rev)
+	if err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	// the snapshot carries the comments of the article now, they are not part of revisions.
+	revision.Article = h.withCommentCount(revision.Article)
+	writeJson(w, revision)
+}
+
+// RestoreRevision puts the content of revision 'rev' back on article 'id' as a new revision - POST METHOD.
+// With an If-Match header the article is only restored while it still has that ETag.
+func (h *Handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	articleID, rev, ok := revisionParams(w, r)
+	if !ok {
+		return
+	}
+
+	// only those who can edit the article as PUT does restore it, see checkIfMatch.
+	current, ok := h.checkIfMatch(w, r, articleID)
+	if !ok {
+		return
+	}
+
+	revision, err := h.database.GetRevision(articleID, rev)
+	if err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+
+	restored := revision.Article
+	restored.ID, restored.Version = current.ID, current.Version
+	if err := h.database.UpdateArticle(restored); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+
+	updated, err := h.database.GetArticleByID(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	h.recordRevision(r, "restore", current, updated)
+	h.audit(r, "restore", current, updated)
+
+	w.Header().Set("ETag", representationETag(w, articleETag(updated)))
+	writeJson(w, h.withCommentCount(updated))
+}
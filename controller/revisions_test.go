@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineDiff(t *testing.T) {
+	assert.Equal(t,
+		[]string{" a", "-b", "+c", " d", "+e"},
+		lineDiff([]string{"a", "b", "d"}, []string{"a", "c", "d", "e"}))
+	assert.Equal(t, []string{}, lineDiff(nil, nil))
+	assert.Equal(t, []string{"+a", "+b"}, lineDiff(nil, []string{"a", "b"}))
+
+	// the diff keeps as many lines as the longest common subsequence and gives back both sides.
+	random := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		before, after := randomLines(random), randomLines(random)
+		diff := lineDiff(before, after)
+		var gotBefore, gotAfter []string
+		kept := 0
+		for _, line := range diff {
+			if line[0] != '+' {
+				gotBefore = append(gotBefore, line[1:])
+			}
+			if line[0] != '-' {
+				gotAfter = append(gotAfter, line[1:])
+			}
+			if line[0] == ' ' {
+				kept += 1
+			}
+		}
+		assert.Equal(t, before, gotBefore)
+		assert.Equal(t, after, gotAfter)
+		assert.Equal(t, lcsLength(before, after), kept)
+	}
+}
+
+func randomLines(random *rand.Rand) []string {
+	var lines []string
+	for i := random.Intn(12); i > 0; i-- {
+		lines = append(lines, string(rune('a'+random.Intn(4))))
+	}
+	return lines
+}
+
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] > lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return lcs[0][0]
+}
+
+func TestHandler_TagChangesRecordRevisions(t *testing.T) {
+	h := newMemoryHandler(t)
+	serve(h, "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"first","tags":["aaa","bbb"]}`)
+
+	rr := serve(h, "POST", "http://localhost:8984/tags/aaa/rename", `{"name":"ccc"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(h, "DELETE", "http://localhost:8984/tags/bbb", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1/revisions/2", "")
+	var revision Revision
+	json.Unmarshal(rr.Body.Bytes(), &revision)
+	assert.Equal(t, "rename_tag", revision.Action)
+	assert.Equal(t, []string{" title: One", " date: 2018-10-04", "-tags: aaa, bbb", "+tags: ccc, bbb", " body:", " first"}, revision.Diff)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1/revisions/3", "")
+	json.Unmarshal(rr.Body.Bytes(), &revision)
+	assert.Equal(t, "delete_tag", revision.Action)
+	assert.Equal(t, []string{"ccc"}, revision.Article.Tags)
+}
+
+func TestHandler_RevisionsAndRestore(t *testing.T) {
+	h := newMemoryHandler(t)
+
+	rr := serve(h, "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"first\nsecond","tags":["aaa"]}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"One","date":"2018-10-04","body":"first\nchanged","tags":["aaa"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1/revisions", "")
+	var list RevisionList
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	assert.Equal(t, 2, list.Count)
+	assert.Equal(t, "test", list.Revisions[1].Author)
+	assert.Equal(t, "update", list.Revisions[1].Action)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1/revisions/2", "")
+	var revision Revision
+	json.Unmarshal(rr.Body.Bytes(), &revision)
+	assert.Equal(t, []string{" title: One", " date: 2018-10-04", " tags: aaa", " body:", " first", "-second", "+changed"}, revision.Diff)
+
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/revisions/1/restore", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	article, _ := h.database.GetArticleByID(1)
+	assert.Equal(t, "first\nsecond", article.Body)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1/revisions/3", "")
+	json.Unmarshal(rr.Body.Bytes(), &revision)
+	assert.Equal(t, "restore", revision.Action)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1/revisions/9", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestHandler_RevisionsOfDraftForReaders(t *testing.T) {
+	h := newMemoryHandler(t)
+	accounts["writer"] = account{password: "password"}
+	defer delete(accounts, "writer")
+
+	rr := serveAs(h, "writer", "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"secret","tags":["aaa"]}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/articles/1/revisions", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/articles/1/revisions/1", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "secret")
+	for _, rev := range []string{"1", "9"} {
+		rr = serveAs(h, "writer", "POST", "http://localhost:8984/articles/1/revisions/"+rev+"/restore", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "secret")
+	}
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1/revisions/1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/submit", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/publish", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/articles/1/revisions", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// once published only its author and editors restore it.
+	accounts["reader"] = account{password: "password"}
+	defer delete(accounts, "reader")
+	rr = serveAs(h, "reader", "POST", "http://localhost:8984/articles/1/revisions/1/restore", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "writer", "POST", "http://localhost:8984/articles/1/revisions/1/restore", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
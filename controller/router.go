@@ -2,16 +2,78 @@
 package controller
 
 import (
+	"os"
+
 	"github.com/gorilla/mux"
 )
 
-var handler = &Handler{Database{}}
+var handler = &Handler{database: newStore(), blobs: newBlobStore()}
+
+// newStore picks the backend from the STORE env variable - mongo unless set to "memory".
+func newStore() Store {
+	if os.Getenv("STORE") == "memory" {
+		return NewMemoryStore()
+	}
+	return &Database{}
+}
 
 func Router() *mux.Router {
+	return newRouter(handler)
+}
+
+func newRouter(h *Handler) *mux.Router {
 	r := mux.NewRouter().StrictSlash(true)
-	r.HandleFunc("/articles", Authentication(handler.ArticlesHandler))
-	r.HandleFunc("/articles/{id}", Authentication(handler.GetArticleByID))
-	r.HandleFunc("/tag/{tagName}/{date}", Authentication(handler.GetArticleByTagNameDate))
-	r.HandleFunc("/article", Authentication(handler.DeleteArticle))
+	r.Use(RequestID)
+	r.Use(Negotiate)
+	r.HandleFunc("/articles", Authentication(h.Idempotent(h.ArticlesHandler)))
+	// registered before the routes below /articles/{id} so slugs like 'comments' are not taken for one.
+	r.HandleFunc("/articles/by-slug/{slug}", Authentication(h.GetArticleBySlug)).Methods("GET")
+	r.HandleFunc("/articles/{id}", Authentication(h.GetArticleByID)).Methods("GET")
+	r.HandleFunc("/articles/{id}", Authentication(h.UpdateArticle)).Methods("PUT")
+	r.HandleFunc("/articles/{id}", Authentication(h.DeleteArticleByID)).Methods("DELETE")
+	r.HandleFunc("/articles/{id}/related", Authentication(h.GetRelatedArticles)).Methods("GET")
+	r.HandleFunc("/articles/{id}/{action:submit|reject|publish|archive|unarchive}", Authentication(h.MoveArticle)).Methods("POST")
+	r.HandleFunc("/articles/{id}/schedule", Authentication(RequireRole(editorRole, h.SchedulePublish))).Methods("PUT")
+	r.HandleFunc("/articles/{id}/schedule", Authentication(RequireRole(editorRole, h.CancelSchedule))).Methods("DELETE")
+	r.HandleFunc("/articles/{id}/comments", Authentication(h.ListComments)).Methods("GET")
+	r.HandleFunc("/articles/{id}/comments", Authentication(h.AddComment)).Methods("POST")
+	r.HandleFunc("/articles/{id}/comments/{cid}", Authentication(h.UpdateComment)).Methods("PUT")
+	r.HandleFunc("/articles/{id}/comments/{cid}", Authentication(h.DeleteComment)).Methods("DELETE")
+	r.HandleFunc("/articles/{id}/comments/{cid}/{action:approve|reject}", Authentication(RequireRole(editorRole, h.ModerateComment))).Methods("POST")
+	r.HandleFunc("/articles/{id}/attachments", Authentication(h.ListAttachments)).Methods("GET")
+	r.HandleFunc("/articles/{id}/attachments", Authentication(h.UploadAttachments)).Methods("POST")
+	r.HandleFunc("/articles/{id}/attachments/{aid}", Authentication(h.GetAttachment)).Methods("GET", "HEAD")
+	r.HandleFunc("/articles/{id}/attachments/{aid}", Authentication(h.DeleteAttachment)).Methods("DELETE")
+	r.HandleFunc("/articles/{id}/revisions", Authentication(h.ListRevisions)).Methods("GET")
+	r.HandleFunc("/articles/{id}/revisions/{rev}", Authentication(h.GetRevision)).Methods("GET")
+	r.HandleFunc("/articles/{id}/revisions/{rev}/restore", Authentication(h.RestoreRevision)).Methods("POST")
+	r.HandleFunc("/tag/{tagName}/{date}", Authentication(h.GetArticleByTagNameDate))
+	r.HandleFunc("/article", Authentication(RequireRole(editorRole, h.DeleteArticle)))
+	r.HandleFunc("/schedule", Authentication(RequireRole(editorRole, h.ListScheduled))).Methods("GET")
+	r.HandleFunc("/trash", Authentication(h.ListTrash)).Methods("GET")
+	r.HandleFunc("/trash/{id}/restore", Authentication(RequireRole(editorRole, h.RestoreFromTrash))).Methods("POST")
+	r.HandleFunc("/trash/{id}", Authentication(RequireRole(editorRole, h.PurgeFromTrash))).Methods("DELETE")
+	r.HandleFunc("/search", Authentication(h.Search)).Methods("GET")
+	r.HandleFunc("/tags", Authentication(h.ListTags)).Methods("GET")
+	// registered before /tags/{tagName} so 'graph' and 'trending' are not taken for a tag name.
+	r.HandleFunc("/tags/graph", Authentication(h.GetTagGraph)).Methods("GET")
+	r.HandleFunc("/tags/trending", Authentication(h.GetTrendingTags)).Methods("GET")
+	r.HandleFunc("/tags/{tagName}", Authentication(h.GetTag)).Methods("GET")
+	r.HandleFunc("/tags/{tagName}", Authentication(RequireRole(editorRole, h.DeleteTag))).Methods("DELETE")
+	r.HandleFunc("/tags/{tagName}/rename", Authentication(RequireRole(editorRole, h.RenameTag))).Methods("POST")
+	r.HandleFunc("/tags/{tagName}/merge", Authentication(RequireRole(editorRole, h.MergeTag))).Methods("POST")
+	r.HandleFunc("/authors", Authentication(h.CreateAuthor)).Methods("POST")
+	r.HandleFunc("/authors", Authentication(h.ListAuthors)).Methods("GET")
+	r.HandleFunc("/authors/{id}", Authentication(h.GetAuthor)).Methods("GET")
+	r.HandleFunc("/authors/{id}", Authentication(h.UpdateAuthor)).Methods("PUT")
+	r.HandleFunc("/authors/{id}", Authentication(h.DeleteAuthor)).Methods("DELETE")
+	r.HandleFunc("/authors/{id}/articles", Authentication(h.GetAuthorArticles)).Methods("GET")
+	// feeds and sitemap only hold published articles, readers, partners and crawlers get them without credentials.
+	r.HandleFunc("/feeds/articles.{format:rss|atom}", h.GetArticlesFeed).Methods("GET", "HEAD")
+	r.HandleFunc("/feeds/tag/{tagName}", h.GetTagFeed).Methods("GET", "HEAD")
+	r.HandleFunc("/sitemap.xml", h.GetSitemap).Methods("GET", "HEAD")
+	r.HandleFunc("/sitemaps/{part:[0-9]+}.xml", h.GetSitemapPart).Methods("GET", "HEAD")
+	r.HandleFunc("/admin/audit", Authentication(RequireRole("admin", h.ListAudit))).Methods("GET")
+	r.HandleFunc("/admin/audit/export", Authentication(RequireRole("admin", h.ExportAudit))).Methods("GET")
 	return r
 }
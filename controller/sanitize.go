@@ -0,0 +1,228 @@
+// Allow-list HTML sanitizer.
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SanitizePolicy decides what is kept of the html of the articles.
+type SanitizePolicy struct {
+	// Elements maps the elements kept to the attributes kept on them.
+	Elements map[string][]string
+	// DropContent are the elements removed together with everything inside.
+	DropContent map[string]bool
+	// URLSchemes allowed in href and src, relative urls are kept too.
+	URLSchemes map[string]bool
+}
+
+// defaultPolicy keeps common text markup, links and images.
+var defaultPolicy = SanitizePolicy{
+	Elements: map[string][]string{
+		"a": {"href", "title"}, "abbr": {"title"}, "b": nil, "blockquote": nil, "br": nil, "code": nil,
+		"dd": nil, "del": nil, "div": nil, "dl": nil, "dt": nil, "em": nil, "figcaption": nil, "figure": nil,
+		"h1": {"id"}, "h2": {"id"}, "h3": {"id"}, "h4": {"id"}, "h5": {"id"}, "h6": {"id"},
+		"hr": nil, "i": nil, "img": {"src", "alt", "title", "width", "height"}, "input": {"type", "checked", "disabled"},
+		"li": nil, "ol": {"start"}, "p": nil, "pre": nil, "q": nil, "s": nil, "small": nil, "span": nil,
+		"strong": nil, "sub": nil, "sup": nil, "table": nil, "tbody": nil, "td": {"align"}, "th": {"align"},
+		"thead": nil, "tr": nil, "u": nil, "ul": nil,
+	},
+	DropContent: map[string]bool{
+		"script": true, "style": true, "iframe": true, "object": true, "embed": true, "template": true,
+		"noscript": true, "title": true, "head": true,
+	},
+	URLSchemes: map[string]bool{"http": true, "https": true, "mailto": true},
+}
+
+// textPolicy keeps no markup at all, titles are stripped with it.
+var textPolicy = SanitizePolicy{DropContent: defaultPolicy.DropContent}
+
+// htmlPolicy is the policy of the article bodies, HTML_POLICY names a json file replacing the default eg:
+// {"elements": {"p": [], "a": ["href"]}, "drop_content": ["script", "style"], "url_schemes": ["https"]}
+var htmlPolicy = loadPolicy(os.Getenv("HTML_POLICY"))
+
+func loadPolicy(path string) SanitizePolicy {
+	if path == "" {
+		return defaultPolicy
+	}
+	var config struct {
+		Elements    map[string][]string `json:"elements"`
+		DropContent []string            `json:"drop_content"`
+		URLSchemes  []string            `json:"url_schemes"`
+	}
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &config)
+	}
+	if err != nil {
+		log.Println("Error: reading HTML_POLICY, using the default policy -", err)
+		return defaultPolicy
+	}
+
+	policy := SanitizePolicy{Elements: config.Elements, DropContent: map[string]bool{}, URLSchemes: map[string]bool{}}
+	for _, name := range config.DropContent {
+		policy.DropContent[strings.ToLower(name)] = true
+	}
+	for _, scheme := range config.URLSchemes {
+		policy.URLSchemes[strings.ToLower(scheme)] = true
+	}
+	return policy
+}
+
+// SanitizeReport counts what the sanitizer removed.
+type SanitizeReport struct {
+	// Elements removed with their text kept.
+	Elements map[string]int `json:"elements,omitempty"`
+	// Content lists the elements removed together with everything inside.
+	Content map[string]int `json:"content,omitempty"`
+	// Attributes removed as element.attribute, urls with a scheme not allowed included.
+	Attributes map[string]int `json:"attributes,omitempty"`
+}
+
+// countName adds one to the count of name, creating counts when nil.
+func countName(counts map[string]int, name string) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[name] += 1
+	return counts
+}
+
+// Empty reports whether nothing was removed.
+func (report SanitizeReport) Empty() bool {
+	return len(report.Elements) == 0 && len(report.Content) == 0 && len(report.Attributes) == 0
+}
+
+// safeURL reports whether the url can be linked without running code.
+func (p SanitizePolicy) safeURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || p.URLSchemes[strings.ToLower(u.Scheme)]
+}
+
+// sanitizeHTML sanitizes s with the policy of the article bodies.
+func sanitizeHTML(s string) string {
+	sanitized, _ := htmlPolicy.Sanitize(s)
+	return sanitized
+}
+
+// voidElements never have content nor an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// textEscaper escapes the text kept by Sanitize, so that no markup can be made of it, eg: by removing
+// the element between "<" and "img".
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Sanitize keeps only the allowed elements and attributes of s; the text of removed elements is kept
+// except for those in DropContent. Sanitizing the result again leaves it as it is.
+func (p SanitizePolicy) Sanitize(s string) (string, SanitizeReport) {
+	var out bytes.Buffer
+	var report SanitizeReport
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	skip := 0
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			// io.EOF at the end of s, reading a string does not fail otherwise.
+			return out.String(), report
+		}
+		token := tokenizer.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if p.DropContent[token.Data] {
+				// browsers ignore the slash of <script/>, what follows is its content; void elements have none.
+				if !voidElements[token.Data] {
+					skip += 1
+				}
+				if skip <= 1 {
+					report.Content = countName(report.Content, token.Data)
+				}
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			attrs, ok := p.Elements[token.Data]
+			if !ok {
+				report.Elements = countName(report.Elements, token.Data)
+				continue
+			}
+			token.Attr = p.allowedAttrs(token, attrs, &report)
+			out.WriteString(token.String())
+		case html.EndTagToken:
+			if p.DropContent[token.Data] {
+				if skip > 0 {
+					skip -= 1
+				}
+				continue
+			}
+			if _, ok := p.Elements[token.Data]; ok && skip == 0 {
+				out.WriteString(token.String())
+			}
+		case html.TextToken:
+			if skip == 0 {
+				textEscaper.WriteString(&out, token.Data)
+			}
+		case html.CommentToken, html.DoctypeToken:
+			if skip == 0 {
+				report.Content = countName(report.Content, "#"+strings.ToLower(tt.String()))
+			}
+		}
+	}
+}
+
+// allowedAttrs returns the attributes of the token in names, dropping urls that are not safe.
+func (p SanitizePolicy) allowedAttrs(token html.Token, names []string, report *SanitizeReport) []html.Attribute {
+	kept := []html.Attribute{}
+	for _, attr := range token.Attr {
+		allowed := false
+		for _, name := range names {
+			if attr.Namespace == "" && attr.Key == name && ((name != "href" && name != "src") || p.safeURL(attr.Val)) {
+				allowed = true
+			}
+		}
+		if allowed {
+			kept = append(kept, attr)
+		} else {
+			report.Attributes = countName(report.Attributes, token.Data+"."+attr.Key)
+		}
+	}
+	return kept
+}
+
+// titleText returns the title of a as text, titles are stored as html without markup.
+func titleText(a Article) string {
+	return html.UnescapeString(a.Title)
+}
+
+// sanitizeArticle sanitizes the articles as they are written: titles of every format keep no markup,
+// bodies are sanitized when they are html. It returns what was removed by field, nil when nothing was.
+func sanitizeArticle(a *Article) map[string]SanitizeReport {
+	var report map[string]SanitizeReport
+	var title, body SanitizeReport
+	a.Title, title = textPolicy.Sanitize(a.Title)
+	if a.Format == formatHTML {
+		a.Body, body = htmlPolicy.Sanitize(a.Body)
+	}
+	for field, stripped := range map[string]SanitizeReport{"title": title, "body": body} {
+		if !stripped.Empty() {
+			if report == nil {
+				report = make(map[string]SanitizeReport)
+			}
+			report[field] = stripped
+		}
+	}
+	return report
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizePolicy_Report(t *testing.T) {
+	sanitized, report := defaultPolicy.Sanitize(`<p onclick="x()">hi<blink>!</blink></p><script>a</script><script>b<script>c</script></script><!-- note --><a href="javascript:x()">y</a>`)
+	assert.Equal(t, `<p>hi!</p><a>y</a>`, sanitized)
+	assert.Equal(t, map[string]int{"blink": 1}, report.Elements)
+	assert.Equal(t, map[string]int{"script": 2, "#comment": 1}, report.Content)
+	assert.Equal(t, map[string]int{"p.onclick": 1, "a.href": 1}, report.Attributes)
+
+	_, report = defaultPolicy.Sanitize(`<p>fine</p>`)
+	assert.True(t, report.Empty())
+
+	// no markup is made of the text, encoded or left once elements are removed.
+	sanitized, _ = textPolicy.Sanitize(`&lt;img src=x onerror=alert(1)&gt;`)
+	assert.Equal(t, `&lt;img src=x onerror=alert(1)&gt;`, sanitized)
+	sanitized, _ = textPolicy.Sanitize(`<<b>img src=x onerror=alert(1)>`)
+	assert.Equal(t, `&lt;img src=x onerror=alert(1)&gt;`, sanitized)
+	sanitized, report = textPolicy.Sanitize(`a<script/><img src=x onerror=alert(1)></script>b<embed src=x>c`)
+	assert.Equal(t, `abc`, sanitized)
+	assert.Equal(t, map[string]int{"script": 1, "embed": 1}, report.Content)
+}
+
+func TestSanitizePolicy_Idempotent(t *testing.T) {
+	for _, s := range []string{
+		`Tom &amp; Jerry don't <b>stop</b>`, `&lt;img src=x onerror=alert(1)&gt;`, `<<b>i</b>mg>`, `a < b & c > d`,
+		`<p title="&quot;x&quot;">1 &lt; 2</p><script/>`, `&amp;lt;`,
+	} {
+		once, _ := defaultPolicy.Sanitize(s)
+		twice, report := defaultPolicy.Sanitize(once)
+		assert.Equal(t, once, twice, s)
+		assert.True(t, report.Empty(), s)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	ioutil.WriteFile(path, []byte(`{"elements":{"p":[],"a":["href"]},"drop_content":["Script"],"url_schemes":["https"]}`), 0644)
+
+	policy := loadPolicy(path)
+	sanitized, _ := policy.Sanitize(`<p><b>a</b> <a href="http://x">b</a> <a href="https://x">c</a></p><script>d</script>`)
+	assert.Equal(t, `<p>a <a>b</a> <a href="https://x">c</a></p>`, sanitized)
+
+	// a policy that can not be read leaves the default in place.
+	assert.Equal(t, defaultPolicy, loadPolicy(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestHandler_SanitizeOnWrite(t *testing.T) {
+	h := newMemoryHandler(t)
+
+	rr := serve(h, "POST", "http://localhost:8984/articles", `{"title":"<i>One</i><script>x</script>","date":"2018-10-04","body":"<p>ok</p><script>alert(1)</script>","tags":["aaa"],"format":"html"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var article Article
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "One", article.Title)
+	assert.Equal(t, "<p>ok</p>", article.Body)
+	assert.Equal(t, map[string]int{"i": 1}, article.Sanitized["title"].Elements)
+	assert.Equal(t, map[string]int{"script": 1}, article.Sanitized["body"].Content)
+
+	// the report is not stored with the article.
+	rr = serve(h, "GET", "http://localhost:8984/articles/1", "")
+	assert.NotContains(t, rr.Body.String(), "sanitized")
+
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"One","date":"2018-10-04","body":"<p>ok</p>","tags":["aaa"],"format":"html"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "sanitized")
+
+	// only html bodies are sanitized, other formats are at rendering.
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"One","date":"2018-10-04","body":"a <script>","tags":["aaa"],"format":"markdown"}`)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "a <script>", article.Body)
+
+	// titles of every format keep no markup, their text stays escaped.
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"Tom & Jerry don't <b>stop</b>","date":"2018-10-04","body":"b","tags":["aaa"],"format":"plain"}`)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "Tom &amp; Jerry don't stop", article.Title)
+	assert.Equal(t, map[string]int{"b": 1}, article.Sanitized["title"].Elements)
+	assert.Equal(t, "tom-jerry-dont-stop", article.Slug)
+
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"&lt;img src=x onerror=alert(1)&gt;","date":"2018-10-04","body":"b","tags":["aaa"],"format":"plain"}`)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "&lt;img src=x onerror=alert(1)&gt;", article.Title)
+
+	// saving the title as answered leaves it as it is.
+	article.Sanitized = nil
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1", `{"title":"&lt;img src=x onerror=alert(1)&gt;","date":"2018-10-04","body":"c","tags":["aaa"],"format":"plain"}`)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "&lt;img src=x onerror=alert(1)&gt;", article.Title)
+	assert.Nil(t, article.Sanitized)
+}
@@ -0,0 +1,194 @@
+// Scheduled publishing of draft articles.
+package controller
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// schedulerPoll is the longest the scheduler sleeps, so publications scheduled by other
+// processes sharing the store are not missed for long.
+const schedulerPoll = time.Minute
+
+// schedulable reports whether an article in status may be scheduled for publishing.
+func schedulable(status string) bool {
+	return status == statusDraft || status == statusInReview
+}
+
+// publishDue publishes the scheduled articles whose time has come and returns when the next one is due,
+// the zero time when none is left.
+func (h *Handler) publishDue(now time.Time) (time.Time, error) {
+	articles, err := h.database.ListArticles(ArticleFilter{Scheduled: true})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	for _, a := range articles {
+		if !schedulable(articleStatus(a)) {
+			continue
+		}
+		if a.PublishAt.After(now) {
+			if next.IsZero() || a.PublishAt.Before(next) {
+				next = *a.PublishAt
+			}
+			continue
+		}
+		// an article changed meanwhile fails the version check and is retried on the next run.
+		if err := h.database.UpdateStatus(a.ID, a.Version, statusPublished); err != nil {
+			log.Println("Error: publishing scheduled article", a.ID, err)
+			continue
+		}
+		published, err := h.database.GetArticleByID(a.ID)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		h.audit(nil, "publish", a, published)
+		log.Println("Published scheduled article", a.ID)
+	}
+	return next, nil
+}
+
+// runScheduler publishes scheduled articles when they are due. The queue is read from the store on
+// every run, so publications scheduled before a restart are kept.
+func (h *Handler) runScheduler() {
+	for {
+		wait := schedulerPoll
+		next, err := h.publishDue(time.Now().UTC())
+		if err != nil {
+			log.Println("Error: running the publish scheduler,", err)
+		} else if !next.IsZero() && time.Until(next) < wait {
+			wait = time.Until(next)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-h.scheduled:
+			timer.Stop()
+		}
+	}
+}
+
+// StartPublishScheduler runs the publish scheduler in the background.
+func StartPublishScheduler() {
+	handler.scheduled = make(chan struct{}, 1)
+	go handler.runScheduler()
+}
+
+// wakeScheduler makes the scheduler look at the queue again.
+func (h *Handler) wakeScheduler() {
+	select {
+	case h.scheduled <- struct{}{}:
+	default:
+	}
+}
+
+// SchedulePublish sets the time article 'id' gets published, body {"publish_at":"2018-10-05T08:00:00Z"} - PUT METHOD.
+// Only drafts and articles in review can be scheduled. With an If-Match header the article is only
+// scheduled while it still has that ETag.
+func (h *Handler) SchedulePublish(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Println("Error: getting the product ID, ", err)
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	var schedule Schedule
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err == nil {
+		err = decodeBody(r, body, &schedule)
+	}
+	if err != nil || schedule.PublishAt.IsZero() {
+		http.Error(w, "Error: SchedulePublish - a 'publish_at' time is required eg: 2018-10-05T08:00:00Z.", http.StatusUnprocessableEntity)
+		return
+	}
+	if !schedule.PublishAt.After(time.Now()) {
+		http.Error(w, "Error: SchedulePublish - 'publish_at' must be in the future.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	h.setSchedule(w, r, articleID, &schedule.PublishAt)
+}
+
+// CancelSchedule clears the publish_at time of article 'id' - DELETE METHOD.
+func (h *Handler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Println("Error: getting the product ID, ", err)
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	h.setSchedule(w, r, articleID, nil)
+}
+
+// setSchedule stores the publish_at time of article 'id' and answers the article.
+func (h *Handler) setSchedule(w http.ResponseWriter, r *http.Request, articleID int, at *time.Time) {
+	current, ok := h.checkIfMatch(w, r, articleID)
+	if !ok {
+		return
+	}
+	if status := articleStatus(current); at != nil && !schedulable(status) {
+		http.Error(w, "Error: Can not schedule an article in status "+status+".", http.StatusConflict)
+		return
+	}
+
+	if err := h.database.SchedulePublish(current.ID, current.Version, at); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+
+	updated, err := h.database.GetArticleByID(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	action := "schedule"
+	if at == nil {
+		action = "unschedule"
+	}
+	h.audit(r, action, current, updated)
+	h.wakeScheduler()
+
+	w.Header().Set("ETag", representationETag(w, articleETag(updated)))
+	writeJson(w, h.withCommentCount(updated))
+}
+
+// ListScheduled lists the articles waiting to be published, the next one first - GET METHOD.
+func (h *Handler) ListScheduled(w http.ResponseWriter, r *http.Request) {
+	articles, err := h.database.ListArticles(ArticleFilter{Scheduled: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	result := ScheduleQueue{Articles: []ScheduledArticle{}}
+	for _, a := range articles {
+		if schedulable(articleStatus(a)) {
+			result.Articles = append(result.Articles, ScheduledArticle{
+				ID:        a.ID,
+				Title:     a.Title,
+				Status:    articleStatus(a),
+				PublishAt: *a.PublishAt,
+			})
+		}
+	}
+	sort.SliceStable(result.Articles, func(i, j int) bool {
+		return result.Articles[i].PublishAt.Before(result.Articles[j].PublishAt)
+	})
+	result.Count = len(result.Articles)
+	writeJson(w, result)
+}
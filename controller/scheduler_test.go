@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_SchedulePublish(t *testing.T) {
+	h := newMemoryHandler(t)
+	serve(h, "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"first","tags":["aaa"]}`)
+	serve(h, "POST", "http://localhost:8984/articles", `{"title":"Two","date":"2018-10-04","body":"second","tags":["aaa"]}`)
+
+	rr := serve(h, "PUT", "http://localhost:8984/articles/1/schedule", `{"publish_at":"2001-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	soon, later := time.Now().UTC().Add(time.Hour), time.Now().UTC().Add(2*time.Hour)
+	rr = serve(h, "PUT", "http://localhost:8984/articles/2/schedule", `{"publish_at":"`+later.Format(time.RFC3339)+`"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(h, "PUT", "http://localhost:8984/articles/1/schedule", `{"publish_at":"`+soon.Format(time.RFC3339)+`"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "GET", "http://localhost:8984/schedule", "")
+	var queue ScheduleQueue
+	json.Unmarshal(rr.Body.Bytes(), &queue)
+	assert.Equal(t, 2, queue.Count)
+	assert.Equal(t, 1, queue.Articles[0].ID)
+
+	next, err := h.publishDue(time.Now().UTC())
+	assert.Nil(t, err)
+	assert.Equal(t, soon.Truncate(time.Second), next)
+
+	// the queue is read from the store, as after a restart.
+	next, err = h.publishDue(soon.Add(time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, later.Truncate(time.Second), next)
+	article, _ := h.database.GetArticleByID(1)
+	assert.Equal(t, statusPublished, article.Status)
+	assert.Nil(t, article.PublishAt)
+
+	rr = serve(h, "DELETE", "http://localhost:8984/articles/2/schedule", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	next, _ = h.publishDue(later.Add(time.Minute))
+	assert.True(t, next.IsZero())
+	article, _ = h.database.GetArticleByID(2)
+	assert.Equal(t, statusDraft, article.Status)
+}
@@ -0,0 +1,338 @@
+// Full-text search over article title and body.
+package controller
+
+import (
+	"html"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// title matches weigh more than body matches - same weights are given to the mongo text index.
+	titleWeight = 3
+	bodyWeight  = 1
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	// number of words shown around the first match in a snippet.
+	snippetWords = 30
+)
+
+// words ignored while indexing and querying, mongo applies its own list for $text.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "was": true, "with": true,
+}
+
+// tokenize lower cases the text and splits it into words on anything that is not a letter or digit.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// keywords drops the stop words from tokens.
+func keywords(tokens []string) []string {
+	var list []string
+	for _, t := range tokens {
+		if !stopWords[t] {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+// parseSearchQuery splits the raw query into single terms and "quoted phrases".
+func parseSearchQuery(text string) SearchQuery {
+	q := SearchQuery{Text: text}
+	parts := strings.Split(text, "\"")
+	for i, part := range parts {
+		// odd parts are inside quotes; an unterminated quote is treated as plain terms.
+		if i%2 == 1 && i != len(parts)-1 {
+			if phrase := tokenize(part); len(phrase) > 0 {
+				q.Phrases = append(q.Phrases, strings.Join(phrase, " "))
+			}
+			continue
+		}
+		q.Terms = append(q.Terms, keywords(tokenize(part))...)
+	}
+	q.Terms = unique(q.Terms)
+	return q
+}
+
+// highlightWords returns every word the snippet of a hit should highlight.
+func (q SearchQuery) highlightWords() map[string]bool {
+	words := make(map[string]bool)
+	for _, t := range q.Terms {
+		words[t] = true
+	}
+	for _, p := range q.Phrases {
+		for _, t := range strings.Fields(p) {
+			words[t] = true
+		}
+	}
+	return words
+}
+
+// matchesFilters reports whether the article passes the status, tag and date filters of the query.
+func (q SearchQuery) matchesFilters(a Article) bool {
+	if q.Published && articleStatus(a) != statusPublished {
+		return false
+	}
+	if q.From != "" && a.Date < q.From {
+		return false
+	}
+	if q.To != "" && a.Date > q.To {
+		return false
+	}
+	if len(q.Tags) == 0 {
+		return true
+	}
+	for _, want := range q.Tags {
+		for _, tag := range a.Tags {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// snippet returns a window of the body around the first matching word with matches wrapped in <em>.
+func snippet(body string, words map[string]bool) string {
+	fields := strings.Fields(body)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	first := -1
+	for i, f := range fields {
+		if matchesAny(f, words) {
+			first = i
+			break
+		}
+	}
+	start := 0
+	if first > snippetWords/3 {
+		start = first - snippetWords/3
+	}
+	end := start + snippetWords
+	if end > len(fields) {
+		end = len(fields)
+	}
+
+	var out []string
+	for _, f := range fields[start:end] {
+		if matchesAny(f, words) {
+			out = append(out, "<em>"+html.EscapeString(f)+"</em>")
+		} else {
+			out = append(out, html.EscapeString(f))
+		}
+	}
+	result := strings.Join(out, " ")
+	if start > 0 {
+		result = "..." + result
+	}
+	if end < len(fields) {
+		result += "..."
+	}
+	return result
+}
+
+func matchesAny(field string, words map[string]bool) bool {
+	for _, t := range tokenize(field) {
+		if words[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// posting counts the occurrences of one term inside one article.
+type posting struct {
+	title int
+	body  int
+}
+
+// invertedIndex is the text index used by the in-memory store.
+type invertedIndex struct {
+	postings map[string]map[int]*posting
+	titles   map[int][]string
+	bodies   map[int][]string
+}
+
+func newInvertedIndex() *invertedIndex {
+	return &invertedIndex{
+		postings: make(map[string]map[int]*posting),
+		titles:   make(map[int][]string),
+		bodies:   make(map[int][]string),
+	}
+}
+
+// add indexes title and body of the article.
+func (idx *invertedIndex) add(a Article) {
+	idx.titles[a.ID] = tokenize(titleText(a))
+	idx.bodies[a.ID] = tokenize(a.Body)
+	for _, t := range idx.titles[a.ID] {
+		idx.posting(t, a.ID).title++
+	}
+	for _, t := range idx.bodies[a.ID] {
+		idx.posting(t, a.ID).body++
+	}
+}
+
+func (idx *invertedIndex) posting(term string, id int) *posting {
+	docs, ok := idx.postings[term]
+	if !ok {
+		docs = make(map[int]*posting)
+		idx.postings[term] = docs
+	}
+	p, ok := docs[id]
+	if !ok {
+		p = &posting{}
+		docs[id] = p
+	}
+	return p
+}
+
+// remove drops the article from the index.
+func (idx *invertedIndex) remove(id int) {
+	for _, tokens := range [][]string{idx.titles[id], idx.bodies[id]} {
+		for _, t := range tokens {
+			if docs, ok := idx.postings[t]; ok {
+				delete(docs, id)
+				if len(docs) == 0 {
+					delete(idx.postings, t)
+				}
+			}
+		}
+	}
+	delete(idx.titles, id)
+	delete(idx.bodies, id)
+}
+
+// search returns the relevance score of every article matching the query.
+// Any single term is enough to match while every phrase has to be present.
+func (idx *invertedIndex) search(q SearchQuery) map[int]float64 {
+	scores := make(map[int]float64)
+	total := float64(len(idx.titles))
+
+	var phraseTerms []string
+	for _, p := range q.Phrases {
+		phraseTerms = append(phraseTerms, strings.Fields(p)...)
+	}
+	for _, t := range unique(append(append([]string{}, q.Terms...), phraseTerms...)) {
+		docs := idx.postings[t]
+		if len(docs) == 0 {
+			continue
+		}
+		idf := math.Log(1 + total/float64(len(docs)))
+		for id, p := range docs {
+			length := float64(len(idx.titles[id]) + len(idx.bodies[id]))
+			tf := float64(titleWeight*p.title + bodyWeight*p.body)
+			scores[id] += idf * tf / (1 + math.Log(1+length))
+		}
+	}
+
+	for id := range scores {
+		for _, p := range q.Phrases {
+			if !containsPhrase(idx.titles[id], p) && !containsPhrase(idx.bodies[id], p) {
+				delete(scores, id)
+				break
+			}
+		}
+	}
+	return scores
+}
+
+// containsPhrase reports whether the phrase words appear consecutively in tokens.
+func containsPhrase(tokens []string, phrase string) bool {
+	words := strings.Fields(phrase)
+	if len(words) == 0 {
+		return false
+	}
+	for i := 0; i+len(words) <= len(tokens); i++ {
+		match := true
+		for j, w := range words {
+			if tokens[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// sortHits orders hits by descending score, newest article first on ties.
+func sortHits(hits []SearchHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID > hits[j].ID
+	})
+}
+
+// Search runs a full-text query given as 'q' with optional 'tag', 'from', 'to' and 'limit' - GET METHOD.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query := parseSearchQuery(params.Get("q"))
+	if len(query.Terms) == 0 && len(query.Phrases) == 0 {
+		http.Error(w, "Error: Search query is empty.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	for _, tags := range params["tag"] {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				query.Tags = append(query.Tags, tag)
+			}
+		}
+	}
+
+	var err error
+	if query.From, query.To, err = dateRange(params); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	query.Limit, err = intParam(params.Get("limit"), defaultSearchLimit)
+	if err != nil || query.Limit <= 0 {
+		http.Error(w, "Error: Invalid limit.", http.StatusUnprocessableEntity)
+		return
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+
+	query.Published = publishedOnly(r)
+	hits, err := h.database.Search(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	ids := []int{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	counts := h.commentCounts(ids...)
+	words := query.highlightWords()
+	for i := range hits {
+		hits[i].Snippet = snippet(hits[i].Body, words)
+		hits[i].CommentCount = counts[hits[i].ID]
+	}
+
+	writeJson(w, SearchResult{Query: query.Text, Count: len(hits), Results: hits})
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var searchArticles = []Article{
+	{Title: "Global Warming", Date: "2018-10-04", Body: "Change in climate and vegetation across the world.", Tags: []string{"world", "climate"}},
+	{Title: "Potato chips", Date: "2016-09-22", Body: "Some text about how potato chips are great for the climate of a party.", Tags: []string{"health"}},
+	{Title: "Climate summit", Date: "2018-11-01", Body: "Leaders talk about change in climate policy.", Tags: []string{"world", "politics"}},
+}
+
+func TestHandler_SearchRanksTitleMatchesFirst(t *testing.T) {
+	rr := serve(newMemoryHandler(t, searchArticles...), "GET", "http://localhost:8984/search?q=climate", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var result SearchResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, result.Count)
+	assert.Equal(t, 3, result.Results[0].ID)
+	assert.Contains(t, result.Results[0].Snippet, "<em>climate</em>")
+}
+
+func TestHandler_SearchPhraseAndFilters(t *testing.T) {
+	h := newMemoryHandler(t, searchArticles...)
+
+	rr := serve(h, "GET", `http://localhost:8984/search?q="change+in+climate"`, "")
+	var result SearchResult
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, 2, result.Count)
+
+	rr = serve(h, "GET", `http://localhost:8984/search?q="change+in+climate"&tag=politics&from=20181015`, "")
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, 1, result.Count)
+	assert.Equal(t, 3, result.Results[0].ID)
+}
+
+func TestHandler_SearchEmptyQuery(t *testing.T) {
+	rr := serve(newMemoryHandler(t), "GET", "http://localhost:8984/search?q=the", "")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, "Error: Search query is empty.\n", rr.Body.String())
+}
+
+func TestHandler_SearchSkipsDraftsForReaders(t *testing.T) {
+	h := newMemoryHandler(t, searchArticles[0], Article{Title: "Climate draft", Date: "2018-10-05", Body: "Unpublished climate plans.", Status: statusDraft})
+	accounts["writer"] = account{password: "password"}
+	defer delete(accounts, "writer")
+
+	rr := serveAs(h, "writer", "GET", "http://localhost:8984/search?q=climate", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var result SearchResult
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, 1, result.Count)
+	assert.Equal(t, "Global Warming", result.Results[0].Title)
+
+	rr = serve(h, "GET", "http://localhost:8984/search?q=climate", "")
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, 2, result.Count)
+}
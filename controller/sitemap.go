@@ -0,0 +1,206 @@
+// XML sitemap of the published articles and their tags.
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapSize is the most urls of a sitemap file, above it /sitemap.xml is an index of several files.
+var sitemapSize = envInt("SITEMAP_SIZE", 50000)
+
+// sitemapArticle is what the sitemap keeps of a published article.
+type sitemapArticle struct {
+	path    string
+	tags    []string
+	lastMod time.Time
+}
+
+// sitemapURL is a path of the sitemap with when it last changed.
+type sitemapURL struct {
+	path    string
+	lastMod time.Time
+}
+
+// sitemap keeps the published articles, built from the store on first use and updated with every
+// change recorded by Handler.audit. The zero value is ready to use.
+type sitemap struct {
+	mutex    sync.Mutex
+	articles map[int]sitemapArticle
+	// urls is the sorted list of articles and tag pages, nil after a change.
+	urls []sitemapURL
+}
+
+// sitemapEntry returns what the sitemap keeps of the article, false when it does not list it.
+func sitemapEntry(a Article, lastMod time.Time) (sitemapArticle, bool) {
+	if a.ID == 0 || a.DeletedAt != nil || articleStatus(a) != statusPublished {
+		return sitemapArticle{}, false
+	}
+	return sitemapArticle{path: articlePath(a), tags: unique(a.Tags), lastMod: lastMod}, true
+}
+
+// change replaces the article 'before' by 'after' in the sitemap, an empty one (ID 0) is left out.
+func (s *sitemap) change(before, after Article) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.articles == nil {
+		// not built yet, it reads the store when it is.
+		return
+	}
+	delete(s.articles, before.ID)
+	if entry, ok := sitemapEntry(after, time.Now().UTC()); ok {
+		s.articles[after.ID] = entry
+	}
+	s.urls = nil
+}
+
+// list returns the urls of the sitemap, articles by id first then the tag pages by name.
+func (s *sitemap) list(database Store) ([]sitemapURL, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.articles == nil {
+		articles, err := database.ListArticles(ArticleFilter{})
+		if err != nil {
+			return nil, err
+		}
+		s.articles = make(map[int]sitemapArticle)
+		for _, a := range articles {
+			if entry, ok := sitemapEntry(a, publishedAt(a)); ok {
+				s.articles[a.ID] = entry
+			}
+		}
+	}
+	if s.urls != nil {
+		return s.urls, nil
+	}
+
+	ids := []int{}
+	tags := make(map[string]time.Time)
+	for id, entry := range s.articles {
+		ids = append(ids, id)
+		for _, tag := range entry.tags {
+			if entry.lastMod.After(tags[tag]) {
+				tags[tag] = entry.lastMod
+			}
+		}
+	}
+	sort.Ints(ids)
+	names := []string{}
+	for tag := range tags {
+		names = append(names, tag)
+	}
+	sort.Strings(names)
+
+	s.urls = make([]sitemapURL, 0, len(ids)+len(names))
+	for _, id := range ids {
+		s.urls = append(s.urls, sitemapURL{path: s.articles[id].path, lastMod: s.articles[id].lastMod})
+	}
+	for _, tag := range names {
+		s.urls = append(s.urls, sitemapURL{path: "/tags/" + url.PathEscape(tag), lastMod: tags[tag]})
+	}
+	return s.urls, nil
+}
+
+// lastModified returns the newest change of urls in the sitemap format, empty when unknown.
+func lastModified(urls []sitemapURL) string {
+	var newest time.Time
+	for _, u := range urls {
+		if u.lastMod.After(newest) {
+			newest = u.lastMod
+		}
+	}
+	if newest.IsZero() {
+		return ""
+	}
+	return newest.UTC().Format(time.RFC3339)
+}
+
+// writeXML answers data as XML, cached for an hour and validated by its ETag.
+func writeXML(w http.ResponseWriter, r *http.Request, data interface{}) {
+	body, err := xml.MarshalIndent(data, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	body = append([]byte(xml.Header), body...)
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// writeURLSet answers urls as a sitemap file.
+func writeURLSet(w http.ResponseWriter, r *http.Request, urls []sitemapURL) {
+	set := URLSet{XMLNS: sitemapNamespace, URLs: []SitemapURL{}}
+	for _, u := range urls {
+		set.URLs = append(set.URLs, SitemapURL{Loc: absoluteURL(r, u.path), LastMod: lastModified([]sitemapURL{u})})
+	}
+	writeXML(w, r, set)
+}
+
+// GetSitemap answers the sitemap, an index of sitemap files above SITEMAP_SIZE urls - GET METHOD.
+func (h *Handler) GetSitemap(w http.ResponseWriter, r *http.Request) {
+	urls, err := h.sitemap.list(h.database)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	if len(urls) <= sitemapSize {
+		writeURLSet(w, r, urls)
+		return
+	}
+
+	index := SitemapIndex{XMLNS: sitemapNamespace}
+	for part, start := 1, 0; start < len(urls); part, start = part+1, start+sitemapSize {
+		end := start + sitemapSize
+		if end > len(urls) {
+			end = len(urls)
+		}
+		index.Sitemaps = append(index.Sitemaps, SitemapRef{
+			Loc:     absoluteURL(r, "/sitemaps/"+strconv.Itoa(part)+".xml"),
+			LastMod: lastModified(urls[start:end]),
+		})
+	}
+	writeXML(w, r, index)
+}
+
+// GetSitemapPart answers file 'part' of a sitemap split in several - GET METHOD.
+func (h *Handler) GetSitemapPart(w http.ResponseWriter, r *http.Request) {
+	part, _ := strconv.Atoi(mux.Vars(r)["part"])
+
+	urls, err := h.sitemap.list(h.database)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	start := (part - 1) * sitemapSize
+	if part < 1 || start >= len(urls) {
+		http.Error(w, "Error: Sitemap not found.", http.StatusNotFound)
+		return
+	}
+	end := start + sitemapSize
+	if end > len(urls) {
+		end = len(urls)
+	}
+	writeURLSet(w, r, urls[start:end])
+}
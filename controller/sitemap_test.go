@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// getSitemap fetches a sitemap file without credentials.
+func getSitemap(h *Handler, url string, data interface{}) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	rr := httptest.NewRecorder()
+	newRouter(h).ServeHTTP(rr, req)
+	xml.Unmarshal(rr.Body.Bytes(), data)
+	return rr
+}
+
+func TestHandler_Sitemap(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa", "b b", "c/d%"}},
+		Article{Title: "Two", Date: "2018-10-05", Body: "second", Tags: []string{"aaa"}, Status: statusInReview},
+	)
+
+	var set URLSet
+	rr := getSitemap(h, "http://localhost:8984/sitemap.xml", &set)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, []SitemapURL{
+		{Loc: "http://localhost:8984/articles/by-slug/one", LastMod: "2018-10-04T00:00:00Z"},
+		{Loc: "http://localhost:8984/tags/aaa", LastMod: "2018-10-04T00:00:00Z"},
+		{Loc: "http://localhost:8984/tags/b%20b", LastMod: "2018-10-04T00:00:00Z"},
+		{Loc: "http://localhost:8984/tags/c%2Fd%25", LastMod: "2018-10-04T00:00:00Z"},
+	}, set.URLs)
+
+	// published articles are added as they change, articles moved to trash removed.
+	rr = serve(h, "POST", "http://localhost:8984/articles/2/publish", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(h, "DELETE", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	set = URLSet{}
+	getSitemap(h, "http://localhost:8984/sitemap.xml", &set)
+	assert.Equal(t, 2, len(set.URLs))
+	assert.Equal(t, "http://localhost:8984/articles/by-slug/two", set.URLs[0].Loc)
+	assert.NotEqual(t, "2018-10-05T00:00:00Z", set.URLs[0].LastMod)
+	assert.Equal(t, "http://localhost:8984/tags/aaa", set.URLs[1].Loc)
+}
+
+func TestHandler_SitemapIndex(t *testing.T) {
+	defer func(size int) { sitemapSize = size }(sitemapSize)
+	sitemapSize = 2
+	h := newMemoryHandler(t,
+		Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}},
+		Article{Title: "Two", Date: "2018-10-05", Body: "second", Tags: []string{"bbb"}},
+	)
+
+	var index SitemapIndex
+	rr := getSitemap(h, "http://localhost:8984/sitemap.xml", &index)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []SitemapRef{
+		{Loc: "http://localhost:8984/sitemaps/1.xml", LastMod: "2018-10-05T00:00:00Z"},
+		{Loc: "http://localhost:8984/sitemaps/2.xml", LastMod: "2018-10-05T00:00:00Z"},
+	}, index.Sitemaps)
+
+	var set URLSet
+	getSitemap(h, "http://localhost:8984/sitemaps/2.xml", &set)
+	assert.Equal(t, []SitemapURL{
+		{Loc: "http://localhost:8984/tags/aaa", LastMod: "2018-10-04T00:00:00Z"},
+		{Loc: "http://localhost:8984/tags/bbb", LastMod: "2018-10-05T00:00:00Z"},
+	}, set.URLs)
+
+	rr = getSitemap(h, "http://localhost:8984/sitemaps/3.xml", &set)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
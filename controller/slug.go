@@ -0,0 +1,142 @@
+// Human-readable article slugs.
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"awesomeProject/errors"
+	"github.com/gorilla/mux"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength keeps the urls short, slugs are cut between words.
+const maxSlugLength = 80
+
+// transliterations of the letters that do not decompose into a latin letter and accents.
+var transliterations = map[rune]string{
+	// apostrophes join the parts of a word.
+	'\'': "", '’': "",
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'þ': "th", 'ł': "l", 'ı': "i", 'ħ': "h",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k",
+	'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t",
+	'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// slugify turns a title into lower case ascii words joined by dashes, eg: "Crème Brûlée!" is "creme-brulee".
+// Titles without any letter that can be written in ascii give "article".
+func slugify(title string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range norm.NFKD.String(strings.ToLower(title)) {
+		if unicode.Is(unicode.Mn, r) {
+			// accents decomposed from their letter.
+			continue
+		}
+		text, ok := transliterations[r]
+		if !ok && r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			text, ok = string(r), true
+		}
+		if !ok {
+			dash = true
+			continue
+		}
+		if dash && slug.Len() > 0 && text != "" {
+			slug.WriteByte('-')
+		}
+		if text != "" {
+			dash = false
+		}
+		slug.WriteString(text)
+	}
+
+	result := slug.String()
+	if len(result) > maxSlugLength {
+		result = result[:maxSlugLength]
+		if cut := strings.LastIndexByte(result, '-'); cut > 0 {
+			result = result[:cut]
+		}
+	}
+	if result == "" {
+		return "article"
+	}
+	return result
+}
+
+// hasSlugBase reports whether slug is base or base with a collision suffix.
+func hasSlugBase(slug, base string) bool {
+	if slug == base {
+		return true
+	}
+	suffix := strings.TrimPrefix(slug, base+"-")
+	n, err := strconv.Atoi(suffix)
+	return suffix != slug && err == nil && n > 1
+}
+
+// assignSlug sets the slug of a from its title; current is the article as stored, empty for a new one.
+// The slug only changes with the words of the title, the previous one is then kept in OldSlugs so that
+// it still leads to the article. taken reports whether another article uses a slug.
+func assignSlug(a *Article, current Article, taken func(string) (bool, error)) error {
+	base := slugify(titleText(*a))
+	a.Slug, a.OldSlugs = current.Slug, current.OldSlugs
+	if current.Slug != "" && hasSlugBase(current.Slug, base) {
+		return nil
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		used, err := taken(slug)
+		if err != nil {
+			return err
+		}
+		if !used {
+			break
+		}
+		slug = base + "-" + strconv.Itoa(n)
+	}
+
+	a.Slug, a.OldSlugs = slug, nil
+	for _, old := range current.OldSlugs {
+		if old != slug {
+			a.OldSlugs = append(a.OldSlugs, old)
+		}
+	}
+	if current.Slug != "" {
+		a.OldSlugs = append(a.OldSlugs, current.Slug)
+	}
+	return nil
+}
+
+// GetArticleBySlug retrives the article with 'slug', its former slugs redirect to the current one - GET METHOD.
+func (h *Handler) GetArticleBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+
+	article, err := h.database.GetArticleBySlug(slug)
+	if err == nil && !canRead(r, article) {
+		err = errors.New("Error: Failed to retrive the article with slug, not found")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		log.Println(err.Error())
+		return
+	}
+
+	if article.Slug != slug {
+		target := "/articles/by-slug/" + article.Slug
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+	h.writeArticle(w, r, article)
+}
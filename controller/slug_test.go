@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "creme-brulee", slugify("Crème Brûlée!"))
+	assert.Equal(t, "strasse-in-koln", slugify("  Straße in Köln "))
+	assert.Equal(t, "privet-mir", slugify("Привет, мир"))
+	assert.Equal(t, "dont-panic-42", slugify("Don't panic: 42"))
+	assert.Equal(t, "article", slugify("東京"))
+	assert.Equal(t, strings.Repeat("word-", 15)+"word", slugify(strings.Repeat("word ", 30)))
+}
+
+func TestHasSlugBase(t *testing.T) {
+	assert.True(t, hasSlugBase("abc", "abc"))
+	assert.True(t, hasSlugBase("abc-3", "abc"))
+	assert.False(t, hasSlugBase("abc-1", "abc"))
+	assert.False(t, hasSlugBase("abc-def", "abc"))
+}
+
+func TestSlugConflict(t *testing.T) {
+	assert.True(t, slugConflict(&mgo.LastError{Code: 11000, Err: "E11000 duplicate key error index: blog.articles.$article_slug_unique dup key"}))
+	assert.False(t, slugConflict(&mgo.LastError{Code: 11000, Err: "E11000 duplicate key error index: blog.articles.$article_content_hash dup key"}))
+	assert.False(t, slugConflict(nil))
+}
+
+func TestHandler_GetArticleBySlug(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "Hello World", Date: "2018-10-04", Body: "first body", Tags: []string{"aaa"}},
+		Article{Title: "Hello, world!", Date: "2018-10-05", Body: "second body", Tags: []string{"aaa"}},
+	)
+	first, _ := h.database.GetArticleByID(1)
+	second, _ := h.database.GetArticleByID(2)
+	assert.Equal(t, "hello-world", first.Slug)
+	assert.Equal(t, "hello-world-2", second.Slug)
+
+	rr := serve(h, "GET", "http://localhost:8984/articles/by-slug/hello-world-2", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var article Article
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, 2, article.ID)
+
+	// a change of case or punctuation keeps the slug, other words make a new one.
+	rr = serve(h, "PUT", "http://localhost:8984/articles/2", `{"title":"Hello world","date":"2018-10-05","body":"second body","tags":["aaa"]}`)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "hello-world-2", article.Slug)
+	rr = serve(h, "PUT", "http://localhost:8984/articles/2", `{"title":"Goodbye","date":"2018-10-05","body":"second body","tags":["aaa"]}`)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, "goodbye", article.Slug)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/by-slug/hello-world-2?render=html", "")
+	assert.Equal(t, http.StatusMovedPermanently, rr.Code)
+	assert.Equal(t, "/articles/by-slug/goodbye?render=html", rr.Header().Get("Location"))
+
+	// old slugs stay taken by their article.
+	h.database.AddArticle(Article{Title: "Hello World", Date: "2018-10-06", Body: "third body", Tags: []string{"aaa"}})
+	third, _ := h.database.GetArticleByID(3)
+	assert.Equal(t, "hello-world-3", third.Slug)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/by-slug/comments", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,208 @@
+// Store abstraction shared by the mongo and in-memory backends.
+package controller
+
+import (
+	"time"
+
+	"awesomeProject/errors"
+)
+
+// errVersionConflict is returned when an article changed since the version the caller read.
+var errVersionConflict = errors.New("Error: Article was modified since it was retrived.")
+
+// errAuthorExists is returned when a user who already has an author profile gets another one.
+var errAuthorExists = errors.New("Error: The user already has an author profile.")
+
+// Store is implemented by every article backend the handlers can run on.
+type Store interface {
+	AddArticle(data Article) (int, error)
+	GetArticleByID(id int) (Article, error)
+	// GetArticleBySlug returns the article having 'slug' now or before, see assignSlug.
+	GetArticleBySlug(slug string) (Article, error)
+	GetArticleByTagDate(tagStr, dateStr string) (ArticlesArr, error)
+	// DeleteArticle moves the article with the content of data to trash and returns it as it was.
+	DeleteArticle(data Article) (Article, error)
+	// UpdateArticle replaces title, date, body and tags of the article with data.ID and increments
+	// its version, if the stored version is still data.Version; errVersionConflict is returned otherwise.
+	// A *DuplicateError is returned when the new content matches another article.
+	UpdateArticle(data Article) error
+	// DeleteArticleByID moves the article to trash if its version is still 'version'.
+	DeleteArticleByID(id, version int) error
+	// UpdateStatus sets the workflow status of the article if its version is still 'version' and
+	// increments the version, publishing also sets its published_at time and clears publish_at.
+	UpdateStatus(id, version int, status string) error
+	// SchedulePublish sets or, when nil, clears the publish_at time of the article if its version
+	// is still 'version' and increments the version.
+	SchedulePublish(id, version int, at *time.Time) error
+	// RestoreArticle takes the article out of trash, a *DuplicateError is returned when its
+	// content was added again meanwhile.
+	RestoreArticle(id int) error
+	// PurgeArticle removes an article in trash, its revisions and comments for good and returns it as it was.
+	PurgeArticle(id int) (Article, error)
+
+	// ListArticles returns the articles matching filter ordered by id, or newest first with filter.Newest.
+	ListArticles(filter ArticleFilter) (ArticlesArr, error)
+
+	// Search runs a full-text query over title and body.
+	Search(query SearchQuery) ([]SearchHit, error)
+
+	// ListTags returns every tag with the number of articles matching filter carrying it.
+	ListTags(filter ArticleFilter) ([]TagCount, error)
+	// ReplaceTag swaps tag 'from' for 'to' on every article, an empty 'to' removes the tag.
+	// Each article is updated atomically, the number of updated articles is returned.
+	ReplaceTag(from, to string) (int, error)
+
+	// ReserveIdempotencyKey stores record as pending unless an unexpired record exists for its key,
+	// in which case that record is returned with reserved false.
+	ReserveIdempotencyKey(record IdempotencyRecord) (IdempotencyRecord, bool, error)
+	// CompleteIdempotencyKey stores the response of a reserved key.
+	CompleteIdempotencyKey(record IdempotencyRecord) error
+	// ReleaseIdempotencyKey drops a reserved key so the request can be retried.
+	ReleaseIdempotencyKey(key string) error
+
+	// AddRevision stores revision under the next revision number of its article and returns it.
+	// Revisions are never changed once stored.
+	AddRevision(revision Revision) (Revision, error)
+	// ListRevisions returns the revisions of an article, oldest first.
+	ListRevisions(articleID int) ([]Revision, error)
+	GetRevision(articleID, rev int) (Revision, error)
+
+	// AddAuthor stores a new author profile and returns its id, errAuthorExists is returned when
+	// author.User already has one.
+	AddAuthor(author Author) (int, error)
+	GetAuthor(id int) (Author, error)
+	// GetAuthorByUser returns the author profile of a user.
+	GetAuthorByUser(user string) (Author, error)
+	// ListAuthors returns every author ordered by id.
+	ListAuthors() ([]Author, error)
+	// UpdateAuthor replaces name, bio, contact and avatar url of the author with author.ID.
+	UpdateAuthor(author Author) error
+	DeleteAuthor(id int) error
+
+	// AddComment stores a new comment under the next id and returns it.
+	AddComment(comment Comment) (Comment, error)
+	GetComment(id int) (Comment, error)
+	// ListComments returns every comment of an article ordered by id.
+	ListComments(articleID int) ([]Comment, error)
+	// UpdateComment replaces body, status, deleted and updated_at of the comment with comment.ID.
+	UpdateComment(comment Comment) error
+	// CountComments returns the number of approved comments, not deleted, of every article by id.
+	CountComments(articleIDs ...int) (map[int]int, error)
+
+	// AddAttachment stores the attachment under the next id and returns it.
+	AddAttachment(attachment Attachment) (Attachment, error)
+	GetAttachment(id int) (Attachment, error)
+	// ListAttachments returns the attachments of an article ordered by id.
+	ListAttachments(articleID int) ([]Attachment, error)
+	DeleteAttachment(id int) error
+
+	// AddAuditEntry appends entry to the audit log under the next sequence number and returns it.
+	// Entries are never changed nor removed.
+	AddAuditEntry(entry AuditEntry) (AuditEntry, error)
+	// ListAuditEntries returns the entries matching filter, oldest first.
+	ListAuditEntries(filter AuditFilter) ([]AuditEntry, error)
+}
+
+// ArticleFilter narrows the articles returned by ListArticles, empty fields match everything.
+// Articles in trash are only returned, and then only them, when Deleted is set.
+// Scheduled only returns the articles with a publish_at time.
+type ArticleFilter struct {
+	Tag       string
+	From      string
+	To        string
+	AuthorID  int
+	Deleted   bool
+	Scheduled bool
+	// Published leaves out articles that are not published, see workflow.go.
+	Published bool
+	// Newest orders the articles by date then id, newest first, and Limit keeps only the first ones.
+	Newest bool
+	Limit  int
+}
+
+// AuditFilter narrows the entries returned by ListAuditEntries, zero fields match everything.
+// With a Limit only the latest entries are returned.
+type AuditFilter struct {
+	User      string
+	Action    string
+	ArticleID int
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+// matches reports whether the entry passes the filter.
+func (f AuditFilter) matches(e AuditEntry) bool {
+	if f.User != "" && e.User != f.User {
+		return false
+	}
+	if f.Action != "" && e.Action != f.Action {
+		return false
+	}
+	if f.ArticleID != 0 && e.ArticleID != f.ArticleID {
+		return false
+	}
+	if !f.From.IsZero() && e.Timestamp.Before(f.From) {
+		return false
+	}
+	return f.To.IsZero() || e.Timestamp.Before(f.To)
+}
+
+// newer reports whether article a comes before b in the order of ArticleFilter.Newest.
+func newer(a, b Article) bool {
+	if a.Date != b.Date {
+		return a.Date > b.Date
+	}
+	return a.ID > b.ID
+}
+
+// matches reports whether the article passes the filter.
+func (f ArticleFilter) matches(a Article) bool {
+	if (a.DeletedAt != nil) != f.Deleted {
+		return false
+	}
+	if f.Scheduled && a.PublishAt == nil {
+		return false
+	}
+	if f.Published && articleStatus(a) != statusPublished {
+		return false
+	}
+	if f.AuthorID != 0 && a.AuthorID != f.AuthorID {
+		return false
+	}
+	if f.From != "" && a.Date < f.From {
+		return false
+	}
+	if f.To != "" && a.Date > f.To {
+		return false
+	}
+	return f.Tag == "" || hasTag(a, f.Tag)
+}
+
+func hasTag(a Article, tag string) bool {
+	return contains(a.Tags, tag)
+}
+
+// contains reports whether s is in list.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceTag returns tags with 'from' replaced by 'to' without repeating a tag, an empty 'to' drops 'from'.
+func replaceTag(tags []string, from, to string) []string {
+	result := []string{}
+	for _, t := range tags {
+		if t == from {
+			t = to
+		}
+		if t != "" {
+			result = append(result, t)
+		}
+	}
+	return unique(result)
+}
@@ -0,0 +1,314 @@
+// Tag management handlers.
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// sortTagCounts returns counts as a list, most used tag first and by name on ties.
+func sortTagCounts(counts map[string]int) []TagCount {
+	list := []TagCount{}
+	for tag, count := range counts {
+		list = append(list, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Tag < list[j].Tag
+	})
+	return list
+}
+
+// readTagUpdate decodes the body of a rename or merge request.
+func readTagUpdate(r *http.Request) (TagUpdate, error) {
+	var update TagUpdate
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		return update, err
+	}
+	err = decodeBody(r, body, &update)
+	update.Name = strings.TrimSpace(update.Name)
+	update.Into = strings.TrimSpace(update.Into)
+	return update, err
+}
+
+// tagExists reports whether any article the caller of the request can read carries the tag.
+func (h *Handler) tagExists(r *http.Request, tag string) (bool, error) {
+	articles, err := h.database.ListArticles(ArticleFilter{Tag: tag, Published: publishedOnly(r)})
+	return len(articles) > 0, err
+}
+
+// taggedArticles returns the articles carrying tag, those in trash included as ReplaceTag changes them too.
+func (h *Handler) taggedArticles(tag string) (ArticlesArr, error) {
+	live, err := h.database.ListArticles(ArticleFilter{Tag: tag})
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := h.database.ListArticles(ArticleFilter{Tag: tag, Deleted: true})
+	return append(live, deleted...), err
+}
+
+// ListTags lists all tags with their article counts - GET METHOD.
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.database.ListTags(ArticleFilter{Published: publishedOnly(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	writeJson(w, TagList{Count: len(tags), Tags: tags})
+}
+
+// GetTag retrives the details of one tag - GET METHOD.
+func (h *Handler) GetTag(w http.ResponseWriter, r *http.Request) {
+	tagName := mux.Vars(r)["tagName"]
+
+	articles, err := h.database.ListArticles(ArticleFilter{Tag: tagName})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	articles = readable(r, articles)
+	if len(articles) == 0 {
+		http.Error(w, "Error: Tag not found.", http.StatusNotFound)
+		return
+	}
+
+	result := TagDetails{Tag: tagName, Count: len(articles), Articles: []int{}}
+	related := make(map[string]int)
+	for i := range articles {
+		// newest article first, same as the tag&date query.
+		a := articles[len(articles)-1-i]
+		result.Articles = append(result.Articles, a.ID)
+		if result.FirstDate == "" || a.Date < result.FirstDate {
+			result.FirstDate = a.Date
+		}
+		if a.Date > result.LastDate {
+			result.LastDate = a.Date
+		}
+		for _, tag := range unique(a.Tags) {
+			if tag != tagName {
+				related[tag] += 1
+			}
+		}
+	}
+	result.Related_tags = sortTagCounts(related)
+	writeJson(w, result)
+}
+
+// RenameTag renames a tag on all articles, the new name must not be in use - POST METHOD.
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	tagName := mux.Vars(r)["tagName"]
+
+	update, err := readTagUpdate(r)
+	if err != nil || update.Name == "" || update.Name == tagName {
+		http.Error(w, "Error: RenameTag - a new tag 'name' is required.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	h.moveTag(w, r, tagName, update.Name, false)
+}
+
+// MergeTag moves all articles of a tag into an existing tag - POST METHOD.
+func (h *Handler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	tagName := mux.Vars(r)["tagName"]
+
+	update, err := readTagUpdate(r)
+	if err != nil || update.Into == "" || update.Into == tagName {
+		http.Error(w, "Error: MergeTag - a target tag 'into' is required.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	h.moveTag(w, r, tagName, update.Into, true)
+}
+
+// moveTag replaces 'from' by 'to' on all articles; 'to' has to exist when merging and must not when renaming.
+func (h *Handler) moveTag(w http.ResponseWriter, r *http.Request, from, to string, merge bool) {
+	exists, err := h.tagExists(r, from)
+	if err == nil && !exists {
+		http.Error(w, "Error: Tag not found.", http.StatusNotFound)
+		return
+	}
+	if err == nil {
+		exists, err = h.tagExists(r, to)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	if merge && !exists {
+		http.Error(w, "Error: Target tag not found.", http.StatusNotFound)
+		return
+	}
+	if !merge && exists {
+		http.Error(w, "Error: Tag already exists, merge the tags instead.", http.StatusConflict)
+		return
+	}
+
+	tagged, err := h.taggedArticles(from)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	updated, err := h.database.ReplaceTag(from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	action := "rename_tag"
+	if merge {
+		action = "merge_tag"
+	}
+	h.auditTag(r, action, from, to, tagged)
+	writeJson(w, TagChange{Tag: from, Into: to, ArticlesUpdated: updated})
+}
+
+// DeleteTag removes a tag from all articles - DELETE METHOD.
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tagName := mux.Vars(r)["tagName"]
+
+	tagged, err := h.taggedArticles(tagName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	updated, err := h.database.ReplaceTag(tagName, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	if updated == 0 {
+		http.Error(w, "Error: Tag not found.", http.StatusNotFound)
+		return
+	}
+	h.auditTag(r, "delete_tag", tagName, "", tagged)
+	writeJson(w, TagChange{Tag: tagName, ArticlesUpdated: updated})
+}
+
+// buildTagGraph counts every tag and every pair of tags appearing on the same article.
+// Edges below minWeight are dropped together with the nodes left without an edge.
+func buildTagGraph(articles ArticlesArr, minWeight int) TagGraph {
+	counts := make(map[string]int)
+	pairs := make(map[[2]string]int)
+	for _, a := range articles {
+		tags := unique(a.Tags)
+		sort.Strings(tags)
+		for i, tag := range tags {
+			counts[tag] += 1
+			for _, other := range tags[i+1:] {
+				pairs[[2]string{tag, other}] += 1
+			}
+		}
+	}
+
+	graph := TagGraph{Edges: []TagEdge{}}
+	linked := make(map[string]int)
+	for pair, weight := range pairs {
+		if weight >= minWeight {
+			graph.Edges = append(graph.Edges, TagEdge{Source: pair[0], Target: pair[1], Weight: weight})
+			linked[pair[0]] = counts[pair[0]]
+			linked[pair[1]] = counts[pair[1]]
+		}
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Target < b.Target
+	})
+
+	if minWeight > 1 {
+		counts = linked
+	}
+	graph.Nodes = sortTagCounts(counts)
+	return graph
+}
+
+// dotQuote quotes s as a Graphviz id.
+func dotQuote(s string) string {
+	return "\"" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + "\""
+}
+
+// dot renders the graph in Graphviz DOT language.
+func (g TagGraph) dot() []byte {
+	var out bytes.Buffer
+	out.WriteString("graph tags {\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&out, "\t%s [label=%s];\n", dotQuote(n.Tag), dotQuote(fmt.Sprintf("%s (%d)", n.Tag, n.Count)))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&out, "\t%s -- %s [weight=%d, label=\"%d\"];\n", dotQuote(e.Source), dotQuote(e.Target), e.Weight, e.Weight)
+	}
+	out.WriteString("}\n")
+	return out.Bytes()
+}
+
+// GetTagGraph returns the tag co-occurrence graph over an optional date range - GET METHOD.
+// 'format' selects json (default) or dot, 'min_weight' drops weaker edges.
+func (h *Handler) GetTagGraph(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	filter := ArticleFilter{Published: publishedOnly(r)}
+	var err error
+	if filter.From, filter.To, err = dateRange(params); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	minWeight := 1
+	if weight := params.Get("min_weight"); weight != "" {
+		if minWeight, err = strconv.Atoi(weight); err != nil || minWeight < 1 {
+			http.Error(w, "Error: Invalid min_weight.", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	format := params.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "dot" {
+		http.Error(w, "Error: Invalid format, use json or dot.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	articles, err := h.database.ListArticles(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	graph := buildTagGraph(articles, minWeight)
+	graph.From, graph.To = filter.From, filter.To
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=tags.dot")
+		w.WriteHeader(http.StatusOK)
+		w.Write(graph.dot())
+		return
+	}
+	writeJson(w, graph)
+}
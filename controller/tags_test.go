@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var tagArticles = []Article{
+	{Title: "One", Date: "2018-10-04", Body: "one", Tags: []string{"aaa", "bbb"}},
+	{Title: "Two", Date: "2018-10-05", Body: "two", Tags: []string{"aaa", "ccc"}},
+	{Title: "Three", Date: "2018-10-06", Body: "three", Tags: []string{"ccc"}},
+}
+
+func TestHandler_ListTags(t *testing.T) {
+	rr := serve(newMemoryHandler(t, tagArticles...), "GET", "http://localhost:8984/tags", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var result TagList
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, []TagCount{{"aaa", 2}, {"ccc", 2}, {"bbb", 1}}, result.Tags)
+}
+
+func TestHandler_RenameAndMergeTag(t *testing.T) {
+	h := newMemoryHandler(t, tagArticles...)
+
+	rr := serve(h, "POST", "http://localhost:8984/tags/bbb/rename", `{"name":"ccc"}`)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = serve(h, "POST", "http://localhost:8984/tags/bbb/rename", `{"name":"ddd"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "POST", "http://localhost:8984/tags/aaa/merge", `{"into":"ccc"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"articles_updated":2`)
+
+	article, _ := h.database.GetArticleByID(2)
+	assert.Equal(t, []string{"ccc"}, article.Tags)
+	article, _ = h.database.GetArticleByID(1)
+	assert.Equal(t, []string{"ccc", "ddd"}, article.Tags)
+}
+
+func TestHandler_TagChangesForEditors(t *testing.T) {
+	h := newMemoryHandler(t, tagArticles...)
+	accounts["writer"] = account{password: "password"}
+	defer delete(accounts, "writer")
+
+	rr := serveAs(h, "writer", "POST", "http://localhost:8984/tags/bbb/rename", `{"name":"ddd"}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "writer", "POST", "http://localhost:8984/tags/aaa/merge", `{"into":"ccc"}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "writer", "DELETE", "http://localhost:8984/tags/ccc", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// a tag change makes updates read before it fail rather than undo it.
+	etag := serve(h, "GET", "http://localhost:8984/articles/1", "").Header().Get("ETag")
+	rr = serve(h, "POST", "http://localhost:8984/tags/bbb/rename", `{"name":"ddd"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	req, _ := http.NewRequest("PUT", "http://localhost:8984/articles/1", strings.NewReader(`{"title":"One","date":"2018-10-04","body":"one!","tags":["aaa","bbb"]}`))
+	req.Header.Set("If-Match", etag)
+	assert.Equal(t, http.StatusPreconditionFailed, serveRequest(h, req).Code)
+	article, _ := h.database.GetArticleByID(1)
+	assert.Equal(t, []string{"aaa", "ddd"}, article.Tags)
+	assert.Equal(t, 1, article.Version)
+}
+
+func TestHandler_DeleteTag(t *testing.T) {
+	h := newMemoryHandler(t, tagArticles...)
+
+	rr := serve(h, "DELETE", "http://localhost:8984/tags/ccc", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "GET", "http://localhost:8984/tags/ccc", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestHandler_GetTagGraph(t *testing.T) {
+	h := newMemoryHandler(t, tagArticles...)
+
+	rr := serve(h, "GET", "http://localhost:8984/tags/graph?from=20181005", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var graph TagGraph
+	json.Unmarshal(rr.Body.Bytes(), &graph)
+	assert.Equal(t, []TagCount{{"ccc", 2}, {"aaa", 1}}, graph.Nodes)
+	assert.Equal(t, []TagEdge{{"aaa", "ccc", 1}}, graph.Edges)
+
+	rr = serve(h, "GET", "http://localhost:8984/tags/graph?format=dot", "")
+	assert.Equal(t, "text/vnd.graphviz; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "\t\"aaa\" -- \"bbb\" [weight=1, label=\"1\"];\n")
+}
+
+func TestHandler_GetTrendingTags(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "One", Date: "2018-09-25", Body: "one", Tags: []string{"aaa", "bbb"}},
+		Article{Title: "Two", Date: "2018-10-02", Body: "two", Tags: []string{"aaa"}},
+		Article{Title: "Three", Date: "2018-10-04", Body: "three", Tags: []string{"ccc"}},
+		Article{Title: "Four", Date: "2018-10-07", Body: "four", Tags: []string{"ccc", "aaa"}},
+	)
+
+	rr := serve(h, "GET", "http://localhost:8984/tags/trending?window=week&date=20181005&periods=3", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var result TrendingTags
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, "2018-10-01", result.Start)
+	assert.Equal(t, "2018-10-07", result.End)
+	assert.Equal(t, []string{"2018-09-17", "2018-09-24", "2018-10-01"}, result.Periods)
+	assert.Equal(t, []TrendingTag{
+		{Tag: "ccc", Count: 2, Previous: 0, Growth: 2, Buckets: []int{0, 0, 2}},
+		{Tag: "aaa", Count: 2, Previous: 1, Growth: 1, Buckets: []int{0, 1, 2}},
+	}, result.Tags)
+}
+
+func TestHandler_TagsSkipDraftsForReaders(t *testing.T) {
+	h := newMemoryHandler(t, append(tagArticles,
+		Article{Title: "Draft", Date: "2018-10-05", Body: "draft", Tags: []string{"bbb", "ddd"}, Status: statusDraft})...)
+	accounts["writer"] = account{password: "password"}
+	defer delete(accounts, "writer")
+
+	rr := serveAs(h, "writer", "GET", "http://localhost:8984/tags", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var list TagList
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	assert.Equal(t, []TagCount{{"aaa", 2}, {"ccc", 2}, {"bbb", 1}}, list.Tags)
+
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/tags/graph", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var graph TagGraph
+	json.Unmarshal(rr.Body.Bytes(), &graph)
+	assert.Equal(t, []TagCount{{"aaa", 2}, {"ccc", 2}, {"bbb", 1}}, graph.Nodes)
+
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/tags/trending?window=week&date=20181005", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var trending TrendingTags
+	json.Unmarshal(rr.Body.Bytes(), &trending)
+	for _, tag := range trending.Tags {
+		assert.NotEqual(t, "ddd", tag.Tag)
+		if tag.Tag == "bbb" {
+			assert.Equal(t, 1, tag.Count)
+		}
+	}
+
+	// editors count drafts too.
+	rr = serve(h, "GET", "http://localhost:8984/tags", "")
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	assert.Equal(t, 4, list.Count)
+}
@@ -0,0 +1,145 @@
+// Trash of soft deleted articles, purged for good after a retention period.
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// trashRetention is how long deleted articles stay in trash, set with the TRASH_RETENTION env
+// variable as a duration eg: 168h.
+var trashRetention = envDuration("TRASH_RETENTION", 30*24*time.Hour)
+
+// trashedArticleID reads the article id of the url.
+func trashedArticleID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	articleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Println("Error: getting the product ID, ", err)
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return 0, false
+	}
+	return articleID, true
+}
+
+// ListTrash lists the deleted articles with the time they will be purged - GET METHOD.
+func (h *Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	articles, err := h.database.ListArticles(ArticleFilter{Deleted: true, Published: publishedOnly(r)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	result := TrashList{Retention: trashRetention.String(), Articles: []TrashedArticle{}}
+	for _, a := range articles {
+		result.Articles = append(result.Articles, TrashedArticle{
+			ID:        a.ID,
+			Title:     a.Title,
+			Date:      a.Date,
+			Tags:      a.Tags,
+			DeletedAt: *a.DeletedAt,
+			PurgeAt:   a.DeletedAt.Add(trashRetention),
+		})
+	}
+	result.Count = len(result.Articles)
+	writeJson(w, result)
+}
+
+// RestoreFromTrash takes article 'id' out of trash and returns it - POST METHOD.
+func (h *Handler) RestoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	articleID, ok := trashedArticleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.database.RestoreArticle(articleID); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+
+	restored, err := h.database.GetArticleByID(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	h.audit(r, "undelete", Article{}, restored)
+
+	w.Header().Set("Location", articleURL(r, restored.ID))
+	w.Header().Set("ETag", representationETag(w, articleETag(restored)))
+	writeJson(w, h.withCommentCount(restored))
+}
+
+// PurgeFromTrash deletes article 'id' in trash for good - DELETE METHOD.
+func (h *Handler) PurgeFromTrash(w http.ResponseWriter, r *http.Request) {
+	articleID, ok := trashedArticleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.purgeArticle(r, articleID); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// purgeArticle removes an article in trash and everything kept for it, a nil request stands for the purge job.
+func (h *Handler) purgeArticle(r *http.Request, id int) error {
+	attachments, err := h.database.ListAttachments(id)
+	if err != nil {
+		return err
+	}
+	purged, err := h.database.PurgeArticle(id)
+	if err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		h.removeAttachment(a)
+	}
+	h.audit(r, "purge", purged, Article{})
+	return nil
+}
+
+// purgeTrash purges the articles deleted before 'before' and returns how many were purged.
+func (h *Handler) purgeTrash(before time.Time) (int, error) {
+	articles, err := h.database.ListArticles(ArticleFilter{Deleted: true})
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, a := range articles {
+		if !a.DeletedAt.Before(before) {
+			continue
+		}
+		// an article restored meanwhile is no longer in trash and is left alone.
+		if err := h.purgeArticle(nil, a.ID); err != nil {
+			log.Println("Error: purging article", a.ID, err)
+			continue
+		}
+		purged += 1
+	}
+	return purged, nil
+}
+
+// StartTrashPurger purges the articles older than the trash retention every 'interval' in the background.
+func StartTrashPurger(interval time.Duration) {
+	go func() {
+		for {
+			purged, err := handler.purgeTrash(time.Now().UTC().Add(-trashRetention))
+			if err != nil {
+				log.Println("Error: purging trash,", err)
+			} else if purged > 0 {
+				log.Println("Purged", purged, "articles from trash")
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
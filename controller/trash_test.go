@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_TrashAndRestore(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}},
+		Article{Title: "Two", Date: "2018-10-04", Body: "second", Tags: []string{"aaa"}})
+
+	rr := serve(h, "DELETE", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = serve(h, "GET", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	articles, _ := h.database.GetArticleByTagDate("aaa", "2018-10-04")
+	assert.Equal(t, 1, len(articles))
+	tags, _ := h.database.ListTags(ArticleFilter{})
+	assert.Equal(t, []TagCount{{Tag: "aaa", Count: 1}}, tags)
+
+	rr = serve(h, "GET", "http://localhost:8984/trash", "")
+	var trash TrashList
+	json.Unmarshal(rr.Body.Bytes(), &trash)
+	assert.Equal(t, 1, trash.Count)
+	assert.Equal(t, 1, trash.Articles[0].ID)
+	assert.Equal(t, trash.Articles[0].DeletedAt.Add(trashRetention), trash.Articles[0].PurgeAt)
+
+	rr = serve(h, "POST", "http://localhost:8984/trash/1/restore", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "http://localhost:8984/articles/1", rr.Header().Get("Location"))
+	rr = serve(h, "GET", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "POST", "http://localhost:8984/trash/1/restore", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestHandler_RestoreDuplicate(t *testing.T) {
+	h := newMemoryHandler(t, Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}})
+
+	serve(h, "DELETE", "http://localhost:8984/articles/1", "")
+	// the content of an article in trash can be posted again.
+	rr := serve(h, "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"first","tags":["aaa"]}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = serve(h, "POST", "http://localhost:8984/trash/1/restore", "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestHandler_PurgeTrash(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}},
+		Article{Title: "Two", Date: "2018-10-04", Body: "second", Tags: []string{"aaa"}})
+
+	serve(h, "DELETE", "http://localhost:8984/articles/1", "")
+	purged, err := h.purgeTrash(time.Now().UTC().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = h.purgeTrash(time.Now().UTC().Add(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+	trash, _ := h.database.ListArticles(ArticleFilter{Deleted: true})
+	assert.Equal(t, 0, len(trash))
+
+	// only articles in trash are purged.
+	rr := serve(h, "DELETE", "http://localhost:8984/trash/2", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestHandler_TrashSkipsDraftsForReaders(t *testing.T) {
+	h := newMemoryHandler(t,
+		Article{Title: "One", Date: "2018-10-04", Body: "first", Tags: []string{"aaa"}},
+		Article{Title: "Two", Date: "2018-10-04", Body: "second", Tags: []string{"aaa"}, Status: statusDraft})
+	accounts["writer"] = account{password: "password"}
+	defer delete(accounts, "writer")
+	serve(h, "DELETE", "http://localhost:8984/articles/1", "")
+	serve(h, "DELETE", "http://localhost:8984/articles/2", "")
+
+	rr := serveAs(h, "writer", "GET", "http://localhost:8984/trash", "")
+	var trash TrashList
+	json.Unmarshal(rr.Body.Bytes(), &trash)
+	assert.Equal(t, 1, trash.Count)
+	assert.Equal(t, 1, trash.Articles[0].ID)
+
+	// only editors restore and purge.
+	rr = serveAs(h, "writer", "POST", "http://localhost:8984/trash/2/restore", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "second")
+	rr = serveAs(h, "writer", "DELETE", "http://localhost:8984/trash/1", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	trashed, _ := h.database.ListArticles(ArticleFilter{Deleted: true})
+	assert.Equal(t, 2, len(trashed))
+}
@@ -0,0 +1,165 @@
+// Trending tags computed over day, week and month windows.
+package controller
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// layout of Article.Date.
+	dateLayout = "2006-01-02"
+
+	defaultTrendingPeriods = 4
+	maxTrendingPeriods     = 52
+	defaultTrendingLimit   = 10
+)
+
+// windowStart returns the first day of the day, week (starting monday) or month containing t.
+func windowStart(t time.Time, window string) time.Time {
+	t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch window {
+	case "week":
+		return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	case "month":
+		return t.AddDate(0, 0, 1-t.Day())
+	}
+	return t
+}
+
+// nextWindow returns the start of the window following the one starting at start.
+func nextWindow(start time.Time, window string) time.Time {
+	switch window {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// trendingTags buckets the tag counts of articles into windows starting at starts and ranks the
+// tags seen in the last window by their growth versus the window before.
+func trendingTags(articles ArticlesArr, starts []time.Time, window string) []TrendingTag {
+	buckets := make(map[string][]int)
+	for _, a := range articles {
+		date, err := time.Parse(dateLayout, a.Date)
+		if err != nil {
+			continue
+		}
+		bucket := sort.Search(len(starts), func(i int) bool { return starts[i].After(date) }) - 1
+		if bucket < 0 || !date.Before(nextWindow(starts[len(starts)-1], window)) {
+			continue
+		}
+		for _, tag := range unique(a.Tags) {
+			if _, ok := buckets[tag]; !ok {
+				buckets[tag] = make([]int, len(starts))
+			}
+			buckets[tag][bucket] += 1
+		}
+	}
+
+	result := []TrendingTag{}
+	for tag, counts := range buckets {
+		current, previous := counts[len(counts)-1], counts[len(counts)-2]
+		if current == 0 {
+			continue
+		}
+		// a tag new in this window grows by its count.
+		base := previous
+		if base == 0 {
+			base = 1
+		}
+		result = append(result, TrendingTag{
+			Tag:      tag,
+			Count:    current,
+			Previous: previous,
+			Growth:   float64(current-previous) / float64(base),
+			Buckets:  counts,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Growth != result[j].Growth {
+			return result[i].Growth > result[j].Growth
+		}
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
+
+// GetTrendingTags ranks tags by growth of their article count versus the previous window - GET METHOD.
+// 'window' is day, week (default) or month, 'date' (eg: 20160112) picks the current window and defaults
+// to today, 'periods' is the number of windows returned per tag and 'limit' the number of tags.
+func (h *Handler) GetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	window := params.Get("window")
+	if window == "" {
+		window = "week"
+	}
+	if window != "day" && window != "week" && window != "month" {
+		http.Error(w, "Error: Invalid window, use day, week or month.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	reference := time.Now().UTC()
+	if dateInfo := params.Get("date"); dateInfo != "" {
+		date, err := formatDate(dateInfo)
+		if err == nil {
+			reference, err = time.Parse(dateLayout, date)
+		}
+		if err != nil {
+			http.Error(w, errInvalidDate.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	periods, err := intParam(params.Get("periods"), defaultTrendingPeriods)
+	if err != nil || periods < 2 || periods > maxTrendingPeriods {
+		http.Error(w, "Error: Invalid periods, expected 2 to "+strconv.Itoa(maxTrendingPeriods)+".", http.StatusUnprocessableEntity)
+		return
+	}
+	limit, err := intParam(params.Get("limit"), defaultTrendingLimit)
+	if err != nil || limit <= 0 {
+		http.Error(w, "Error: Invalid limit.", http.StatusUnprocessableEntity)
+		return
+	}
+
+	starts := make([]time.Time, periods)
+	starts[periods-1] = windowStart(reference, window)
+	for i := periods - 2; i >= 0; i-- {
+		starts[i] = windowStart(starts[i+1].AddDate(0, 0, -1), window)
+	}
+	end := nextWindow(starts[periods-1], window).AddDate(0, 0, -1)
+
+	articles, err := h.database.ListArticles(ArticleFilter{
+		From:      starts[0].Format(dateLayout),
+		To:        end.Format(dateLayout),
+		Published: publishedOnly(r),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	result := TrendingTags{
+		Window: window,
+		Start:  starts[periods-1].Format(dateLayout),
+		End:    end.Format(dateLayout),
+		Tags:   trendingTags(articles, starts, window),
+	}
+	for _, start := range starts {
+		result.Periods = append(result.Periods, start.Format(dateLayout))
+	}
+	if len(result.Tags) > limit {
+		result.Tags = result.Tags[:limit]
+	}
+	writeJson(w, result)
+}
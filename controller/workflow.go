@@ -0,0 +1,155 @@
+// Draft and publish workflow of articles.
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"awesomeProject/errors"
+	"github.com/gorilla/mux"
+)
+
+const (
+	statusDraft     = "draft"
+	statusInReview  = "in_review"
+	statusPublished = "published"
+	statusArchived  = "archived"
+
+	// editors review and publish articles and read them in every state.
+	editorRole = "editor"
+)
+
+// transition moves an article from one of the 'from' states to 'to'.
+type transition struct {
+	from   []string
+	to     string
+	editor bool
+}
+
+// transitions are the workflow actions by name, only editors may take those marked editor.
+var transitions = map[string]transition{
+	"submit":    {from: []string{statusDraft}, to: statusInReview},
+	"reject":    {from: []string{statusInReview}, to: statusDraft, editor: true},
+	"publish":   {from: []string{statusInReview}, to: statusPublished, editor: true},
+	"archive":   {from: []string{statusPublished}, to: statusArchived, editor: true},
+	"unarchive": {from: []string{statusArchived}, to: statusDraft, editor: true},
+}
+
+// articleStatus returns the status of a, articles without one are published.
+func articleStatus(a Article) string {
+	if a.Status == "" {
+		return statusPublished
+	}
+	return a.Status
+}
+
+// canRead reports whether the caller of the request may read a, only editors see unpublished articles.
+func canRead(r *http.Request, a Article) bool {
+	return articleStatus(a) == statusPublished || !publishedOnly(r)
+}
+
+// canEdit reports whether the caller of the request may change a, its author and editors may; the
+// caller has to be able to read it too, see checkIfMatch.
+func (h *Handler) canEdit(r *http.Request, a Article) bool {
+	return hasRole(r, editorRole) || h.isAuthor(r, a)
+}
+
+// isAuthor reports whether the caller of the request wrote a.
+func (h *Handler) isAuthor(r *http.Request, a Article) bool {
+	if a.AuthorID == 0 {
+		return false
+	}
+	author, err := h.database.GetAuthorByUser(currentUser(r))
+	return err == nil && author.ID == a.AuthorID
+}
+
+// publishedOnly reports whether the caller of the request only reads published articles.
+func publishedOnly(r *http.Request) bool {
+	return !hasRole(r, editorRole)
+}
+
+// readable returns the articles the caller of the request may read.
+func readable(r *http.Request, articles ArticlesArr) ArticlesArr {
+	result := ArticlesArr{}
+	for _, a := range articles {
+		if canRead(r, a) {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// readableArticle loads the article of the url, answering 404 for articles the caller can not read.
+func (h *Handler) readableArticle(w http.ResponseWriter, r *http.Request) (Article, bool) {
+	articleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		log.Println("Error: getting the product ID, ", err)
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return Article{}, false
+	}
+	article, err := h.database.GetArticleByID(articleID)
+	if err == nil && !canRead(r, article) {
+		err = errors.New("Error: Failed to retrive the article with ID, not found")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		log.Println(err.Error())
+		return Article{}, false
+	}
+	return article, true
+}
+
+// MoveArticle takes the workflow 'action' on article 'id' - POST METHOD.
+// submit, reject, publish, archive and unarchive each move from given states only, else 409 Conflict.
+// With an If-Match header the article is only moved while it still has that ETag.
+func (h *Handler) MoveArticle(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	articleID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Println("Error: getting the product ID, ", err)
+		http.Error(w, "Error: getting the product ID.", http.StatusUnprocessableEntity)
+		return
+	}
+	action := vars["action"]
+	move, ok := transitions[action]
+	if !ok {
+		http.Error(w, "Error: Unknown workflow action.", http.StatusNotFound)
+		return
+	}
+	if move.editor && !hasRole(r, editorRole) {
+		http.Error(w, "Error: "+editorRole+" role required", http.StatusForbidden)
+		return
+	}
+
+	current, ok := h.checkIfMatch(w, r, articleID)
+	if !ok {
+		return
+	}
+	status := articleStatus(current)
+	allowed := false
+	for _, from := range move.from {
+		allowed = allowed || from == status
+	}
+	if !allowed {
+		http.Error(w, "Error: Can not "+action+" an article in status "+status+".", http.StatusConflict)
+		return
+	}
+
+	if err := h.database.UpdateStatus(current.ID, current.Version, move.to); err != nil {
+		http.Error(w, err.Error(), storeErrorStatus(err))
+		log.Println(err.Error())
+		return
+	}
+
+	updated, err := h.database.GetArticleByID(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+	h.audit(r, action, current, updated)
+
+	w.Header().Set("ETag", representationETag(w, articleETag(updated)))
+	writeJson(w, h.withCommentCount(updated))
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_PublishWorkflow(t *testing.T) {
+	h := newMemoryHandler(t)
+	accounts["writer"] = account{password: "password"}
+	defer delete(accounts, "writer")
+
+	rr := serveAs(h, "writer", "POST", "http://localhost:8984/articles", `{"title":"One","date":"2018-10-04","body":"first","tags":["aaa"],"status":"published"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var article Article
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, statusDraft, article.Status)
+
+	// drafts are only read by editors.
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/tag/aaa/20181004", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = serve(h, "GET", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serveAs(h, "writer", "POST", "http://localhost:8984/articles/1/publish", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	// a draft is not found by those who can not read it, whatever they do.
+	for _, method := range []string{"POST /articles/1/submit", "PUT /articles/1", "DELETE /articles/1"} {
+		fields := strings.Fields(method)
+		rr = serveAs(h, "writer", fields[0], "http://localhost:8984"+fields[1], `{"title":"Mine","date":"2018-10-04","body":"mine","tags":["aaa"]}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code, method)
+		assert.NotContains(t, rr.Body.String(), "first", method)
+	}
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/submit", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/submit", "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/publish", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	json.Unmarshal(rr.Body.Bytes(), &article)
+	assert.Equal(t, statusPublished, article.Status)
+	assert.NotNil(t, article.PublishedAt)
+
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/tag/aaa/20181004", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// published articles are only changed by their author and editors.
+	accounts["reader"] = account{password: "password"}
+	defer delete(accounts, "reader")
+	rr = serveAs(h, "reader", "PUT", "http://localhost:8984/articles/1", `{"title":"Theirs","date":"2018-10-04","body":"theirs","tags":["aaa"]}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "reader", "DELETE", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "reader", "POST", "http://localhost:8984/articles?on_duplicate=upsert", `{"title":"One","date":"2018-10-04","body":"first","tags":["aaa"]}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveAs(h, "writer", "PUT", "http://localhost:8984/articles/1", `{"title":"One","date":"2018-10-04","body":"first, edited","tags":["aaa"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(h, "POST", "http://localhost:8984/articles/1/archive", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveAs(h, "writer", "GET", "http://localhost:8984/articles/1", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestArticleStatus(t *testing.T) {
+	// articles stored before the workflow existed stay public.
+	assert.Equal(t, statusPublished, articleStatus(Article{}))
+	assert.Equal(t, statusDraft, articleStatus(Article{Status: statusDraft}))
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"awesomeProject/controller"
 	"github.com/gorilla/handlers"
@@ -12,6 +13,8 @@ import (
 
 func main() {
 	r := controller.Router()
+	controller.StartTrashPurger(time.Hour)
+	controller.StartPublishScheduler()
 	log.Fatal(http.ListenAndServe(port(), handlers.CORS()(r)))
 }
 